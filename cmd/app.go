@@ -1,8 +1,13 @@
 package main
 
 import (
+	"errors"
+	"fmt"
 	"log"
 	"os"
+	"path/filepath"
+	"strings"
+	"time"
 
 	"github.com/siparisa/interview-test-server/internal/controller"
 	"github.com/siparisa/interview-test-server/internal/service"
@@ -26,16 +31,453 @@ func initializeApp() (*gin.Engine, *log.Logger, error) {
 	taxCalculatorURL := os.Getenv("TAX_CALCULATOR_URL")
 	if taxCalculatorURL == "" {
 		taxCalculatorURL = "http://localhost:7070/tax-calculator/tax-year/" // Default URL if not provided
+		logger.Printf("TAX_CALCULATOR_URL is not set, using %s", taxCalculatorURL)
+	}
+
+	taxBracketOptions, err := loadTaxBracketServiceOptions()
+	if err != nil {
+		return nil, nil, err
+	}
+	circuitBreakerOptions, err := loadCircuitBreakerOptions()
+	if err != nil {
+		return nil, nil, err
+	}
+	cacheOptions, err := loadCacheOptions()
+	if err != nil {
+		return nil, nil, err
 	}
 
 	taxService := service.NewTaxService()
-	taxBracketService := service.NewTaxBracketService(taxCalculatorURL)
-	taxController := controller.NewTaxController(taxService, taxBracketService)
 
-	router, err := internal.SetupRouter(logger, taxController)
+	// The impact of a bracket table change is reported on the reference salaries, whether the change is noticed by a
+	// bracket source or by the bracket history, and kept next to the history
+	referenceSalaries, err := getEnvFloats("IMPACT_REFERENCE_SALARIES")
+	if err != nil {
+		return nil, nil, err
+	}
+	bracketImpactService := service.NewBracketImpactService(taxService, service.BracketImpactOptions{ReferenceSalaries: referenceSalaries})
+	bracketReportService, err := service.NewBracketReportService(bracketImpactService, service.BracketReportOptions{
+		File: impactReportFile(os.Getenv("TAX_BRACKET_HISTORY_FILE")),
+	}, logger)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// The tax calculator API is guarded by a circuit breaker and cached
+	remoteTaxBracketService, err := service.NewTaxBracketService(taxCalculatorURL, taxBracketOptions)
+	if err != nil {
+		return nil, nil, err
+	}
+	circuitBreakerService := service.NewCircuitBreakerTaxBracketService(remoteTaxBracketService, circuitBreakerOptions, logger)
+	cachedTaxBracketService := service.NewCachedTaxBracketService(circuitBreakerService, bracketReportService, cacheOptions)
+	circuitBreakerServices := map[string]service.ICircuitBreakerTaxBracketService{
+		service.TaxBracketSourceRemote: circuitBreakerService,
+	}
+
+	embeddedTaxBracketService, err := service.NewEmbeddedTaxBracketService()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	bracketSources := map[string]service.ITaxBracketService{
+		service.TaxBracketSourceRemote:   cachedTaxBracketService,
+		service.TaxBracketSourceEmbedded: embeddedTaxBracketService,
+	}
+	remoteURLs := map[string]string{
+		service.TaxBracketSourceRemote: taxCalculatorURL,
+	}
+	taxYearSources := map[string]service.ITaxYearSource{
+		service.TaxBracketSourceEmbedded: embeddedTaxBracketService,
+	}
+	if taxYearsURL := os.Getenv("TAX_CALCULATOR_YEARS_URL"); taxYearsURL != "" {
+		remoteTaxYearSource, err := service.NewRemoteTaxYearSource(taxYearsURL, taxBracketOptions)
+		if err != nil {
+			return nil, nil, err
+		}
+		taxYearSources[service.TaxBracketSourceRemote] = remoteTaxYearSource
+	}
+
+	// A secondary tax calculator API has its own circuit breaker and cache
+	if secondaryURL := os.Getenv("TAX_CALCULATOR_SECONDARY_URL"); secondaryURL != "" {
+		secondaryTaxBracketService, err := service.NewTaxBracketService(secondaryURL, taxBracketOptions)
+		if err != nil {
+			return nil, nil, err
+		}
+		secondaryCircuitBreakerService := service.NewCircuitBreakerTaxBracketService(secondaryTaxBracketService, circuitBreakerOptions, logger)
+		bracketSources[service.TaxBracketSourceRemoteSecondary] = service.NewCachedTaxBracketService(secondaryCircuitBreakerService, bracketReportService, cacheOptions)
+		remoteURLs[service.TaxBracketSourceRemoteSecondary] = secondaryURL
+		circuitBreakerServices[service.TaxBracketSourceRemoteSecondary] = secondaryCircuitBreakerService
+	}
+
+	// The bracket tables maintained in the TAX_BRACKET_DIR are reloaded when the files change
+	var fileTaxBracketService service.IFileTaxBracketService
+	if taxBracketDir := os.Getenv("TAX_BRACKET_DIR"); taxBracketDir != "" {
+		pollInterval, err := getEnvDuration("TAX_BRACKET_DIR_POLL_INTERVAL")
+		if err != nil {
+			return nil, nil, err
+		}
+
+		fileTaxBracketService, err = service.NewFileTaxBracketService(service.FileTaxBracketOptions{
+			Dir:          taxBracketDir,
+			PollInterval: pollInterval,
+		}, bracketReportService, logger)
+		if err != nil {
+			return nil, nil, err
+		}
+		bracketSources[service.TaxBracketSourceFile] = fileTaxBracketService
+		taxYearSources[service.TaxBracketSourceFile] = fileTaxBracketService
+	}
+
+	// The sources are asked in order of priority, the first healthy one with valid brackets serves the request
+	sourceChain, err := loadBracketSources(bracketSources)
+	if err != nil {
+		return nil, nil, err
+	}
+	sourceChainOptions, err := loadSourceChainOptions()
+	if err != nil {
+		return nil, nil, err
+	}
+	sourceChainService := service.NewSourceChainTaxBracketService(sourceChain, sourceChainOptions, logger)
+	logger.Printf("Bracket sources in order of priority: %s", bracketSourceNames(sourceChain))
+
+	// During a migration the brackets served can be cross-checked against a shadow source without affecting them
+	var taxBracketService service.ITaxBracketService = sourceChainService
+	var shadowService service.IShadowTaxBracketService
+	if shadowSource := os.Getenv("TAX_BRACKET_SHADOW_SOURCE"); shadowSource != "" {
+		shadowBracketService, ok := bracketSources[shadowSource]
+		if !ok {
+			return nil, nil, fmt.Errorf("invalid TAX_BRACKET_SHADOW_SOURCE: %q is not a configured bracket source", shadowSource)
+		}
+		shadowTimeout, err := getEnvDuration("TAX_BRACKET_SHADOW_TIMEOUT")
+		if err != nil {
+			return nil, nil, err
+		}
+
+		// A remote shadow has its own client and circuit breaker and no cache, so its failures and answers cannot
+		// change what the chain serves
+		if shadowURL, ok := remoteURLs[shadowSource]; ok {
+			shadowRemoteService, err := service.NewTaxBracketService(shadowURL, taxBracketOptions)
+			if err != nil {
+				return nil, nil, err
+			}
+			shadowCircuitBreakerService := service.NewCircuitBreakerTaxBracketService(shadowRemoteService, circuitBreakerOptions, logger)
+			circuitBreakerServices["shadow"] = shadowCircuitBreakerService
+			shadowBracketService = shadowCircuitBreakerService
+		}
+
+		shadowService = service.NewShadowTaxBracketService(sourceChainService, shadowBracketService, service.ShadowOptions{
+			Source:  shadowSource,
+			Timeout: shadowTimeout,
+		}, logger)
+		taxBracketService = shadowService
+	}
+
+	var orderedTaxYearSources []service.TaxYearSource
+	for _, source := range sourceChain {
+		if taxYearSource, ok := taxYearSources[source.Name]; ok {
+			orderedTaxYearSources = append(orderedTaxYearSources, service.TaxYearSource{Name: source.Name, Source: taxYearSource})
+		}
+	}
+
+	// The supported tax years are those listed by the bracket sources, refreshed periodically
+	taxYearRefreshInterval, err := getEnvDuration("TAX_YEAR_REFRESH_INTERVAL")
+	if err != nil {
+		return nil, nil, err
+	}
+	taxYearService := service.NewTaxYearService(orderedTaxYearSources, service.TaxYearOptions{RefreshInterval: taxYearRefreshInterval}, logger)
+	taxYearController := controller.NewTaxYearController(taxYearService)
+
+	// The provincial brackets are read from the bracket table directory, falling back to the ones built into the binary
+	jurisdictionTaxBracketService := service.NewJurisdictionTaxBracketService(taxBracketService, fileTaxBracketService)
+
+	// The tax analysts can replace the federal and provincial brackets of a year through the admin API, taking
+	// precedence over all sources
+	bracketOverrideService, err := service.NewBracketOverrideService(jurisdictionTaxBracketService, bracketImpactService, service.BracketOverrideOptions{
+		AuditFile: os.Getenv("ADMIN_AUDIT_FILE"),
+	}, logger)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Every distinct version of the federal and provincial brackets served is recorded in an immutable history
+	bracketHistoryService, err := service.NewBracketHistoryService(bracketOverrideService, bracketReportService, service.BracketHistoryOptions{
+		File: os.Getenv("TAX_BRACKET_HISTORY_FILE"),
+	}, logger)
+	if err != nil {
+		return nil, nil, err
+	}
+	adminController := controller.NewAdminController(bracketOverrideService, bracketHistoryService, taxYearService)
+
+	taxCreditService := service.NewTaxCreditService()
+	provincialTaxService := service.NewProvincialTaxService(taxService, bracketHistoryService)
+	payrollContributionService := service.NewPayrollContributionService()
+	taxController := controller.NewTaxController(taxService, bracketHistoryService, taxYearService, taxCreditService, provincialTaxService, payrollContributionService)
+
+	benefitService := service.NewBenefitService()
+	benefitController := controller.NewBenefitController(benefitService)
+
+	marginalRateService := service.NewMarginalRateService(taxService, provincialTaxService, payrollContributionService, benefitService)
+	marginalRateController := controller.NewMarginalRateController(bracketHistoryService, taxYearService, marginalRateService)
+
+	bracketController := controller.NewBracketController(bracketHistoryService, taxYearService, provincialTaxService)
+
+	// The brackets of the supported tax years are pre-fetched so the first requests do not wait for the sources, the
+	// service only reports ready once they are or the warm-up timed out
+	warmUpOptions, err := loadWarmUpOptions()
+	if err != nil {
+		return nil, nil, err
+	}
+	warmUpService := service.NewWarmUpService(bracketHistoryService, taxYearService, warmUpOptions, logger)
+
+	statusController := controller.NewStatusController(circuitBreakerServices, cachedTaxBracketService, sourceChainService, shadowService, warmUpService)
+
+	adminTokens, err := loadAdminTokens()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	router, err := internal.SetupRouter(logger, taxController, benefitController, marginalRateController, taxYearController, bracketController, statusController, adminController, adminTokens)
 	if err != nil {
 		return nil, nil, err
 	}
 
 	return router, logger, nil
 }
+
+// impactReportFile returns the file the impact reports are kept in next to the bracket history file, e.g.
+// history.impact.jsonl for history.jsonl. Without a history file the reports are kept in memory only.
+func impactReportFile(historyFile string) string {
+	if historyFile == "" {
+		return ""
+	}
+
+	ext := filepath.Ext(historyFile)
+	return strings.TrimSuffix(historyFile, ext) + ".impact" + ext
+}
+
+// loadTaxBracketServiceOptions reads the HTTP client configuration of the tax calculator API from the environment
+// variables, such as TAX_CALCULATOR_ATTEMPT_TIMEOUT and TAX_CALCULATOR_PROXY_URL.
+func loadTaxBracketServiceOptions() (service.TaxBracketServiceOptions, error) {
+	var options service.TaxBracketServiceOptions
+	var err error
+
+	if options.AttemptTimeout, err = getEnvDuration("TAX_CALCULATOR_ATTEMPT_TIMEOUT"); err != nil {
+		return options, err
+	}
+	if options.OverallDeadline, err = getEnvDuration("TAX_CALCULATOR_DEADLINE"); err != nil {
+		return options, err
+	}
+	if options.MaxIdleConns, err = getEnvInt("TAX_CALCULATOR_MAX_IDLE_CONNS"); err != nil {
+		return options, err
+	}
+	if options.MaxIdleConnsPerHost, err = getEnvInt("TAX_CALCULATOR_MAX_IDLE_CONNS_PER_HOST"); err != nil {
+		return options, err
+	}
+	if options.MaxConnsPerHost, err = getEnvInt("TAX_CALCULATOR_MAX_CONNS_PER_HOST"); err != nil {
+		return options, err
+	}
+
+	if options.RetryPolicy.MaxAttempts, err = getEnvInt("TAX_CALCULATOR_MAX_ATTEMPTS"); err != nil {
+		return options, err
+	}
+	if options.RetryPolicy.InitialBackoff, err = getEnvDuration("TAX_CALCULATOR_INITIAL_BACKOFF"); err != nil {
+		return options, err
+	}
+	if options.RetryPolicy.MaxBackoff, err = getEnvDuration("TAX_CALCULATOR_MAX_BACKOFF"); err != nil {
+		return options, err
+	}
+	if options.RetryPolicy.MaxElapsedTime, err = getEnvDuration("TAX_CALCULATOR_MAX_ELAPSED_TIME"); err != nil {
+		return options, err
+	}
+
+	options.ProxyURL = os.Getenv("TAX_CALCULATOR_PROXY_URL")
+	options.CACertFile = os.Getenv("TAX_CALCULATOR_CA_CERT_FILE")
+	options.ClientCertFile = os.Getenv("TAX_CALCULATOR_CLIENT_CERT_FILE")
+	options.ClientKeyFile = os.Getenv("TAX_CALCULATOR_CLIENT_KEY_FILE")
+
+	if options.Authenticator, err = loadRequestAuthenticator(options); err != nil {
+		return options, err
+	}
+
+	return options, nil
+}
+
+// loadRequestAuthenticator reads how the requests to the tax calculator API are authenticated from the
+// TAX_CALCULATOR_AUTH environment variable: none, api-key, oauth2 or hmac, each with its own variables.
+func loadRequestAuthenticator(options service.TaxBracketServiceOptions) (service.IRequestAuthenticator, error) {
+	switch auth := os.Getenv("TAX_CALCULATOR_AUTH"); auth {
+	case "", "none":
+		return nil, nil
+	case "api-key":
+		apiKey := os.Getenv("TAX_CALCULATOR_API_KEY")
+		if apiKey == "" {
+			return nil, errors.New("TAX_CALCULATOR_API_KEY is required for api-key authentication")
+		}
+		return service.NewAPIKeyAuthenticator(os.Getenv("TAX_CALCULATOR_API_KEY_HEADER"), apiKey), nil
+	case "oauth2":
+		oauth2Options := service.OAuth2Options{
+			TokenURL:     os.Getenv("TAX_CALCULATOR_OAUTH2_TOKEN_URL"),
+			ClientID:     os.Getenv("TAX_CALCULATOR_OAUTH2_CLIENT_ID"),
+			ClientSecret: os.Getenv("TAX_CALCULATOR_OAUTH2_CLIENT_SECRET"),
+			Scope:        os.Getenv("TAX_CALCULATOR_OAUTH2_SCOPE"),
+		}
+		if oauth2Options.TokenURL == "" || oauth2Options.ClientID == "" || oauth2Options.ClientSecret == "" {
+			return nil, errors.New("TAX_CALCULATOR_OAUTH2_TOKEN_URL, TAX_CALCULATOR_OAUTH2_CLIENT_ID and TAX_CALCULATOR_OAUTH2_CLIENT_SECRET are required for oauth2 authentication")
+		}
+		return service.NewOAuth2Authenticator(oauth2Options, options)
+	case "hmac":
+		keyID, secret := os.Getenv("TAX_CALCULATOR_HMAC_KEY_ID"), os.Getenv("TAX_CALCULATOR_HMAC_SECRET")
+		if keyID == "" || secret == "" {
+			return nil, errors.New("TAX_CALCULATOR_HMAC_KEY_ID and TAX_CALCULATOR_HMAC_SECRET are required for hmac authentication")
+		}
+		return service.NewHMACAuthenticator(keyID, secret), nil
+	default:
+		return nil, fmt.Errorf("invalid TAX_CALCULATOR_AUTH %q: must be none, api-key, oauth2 or hmac", auth)
+	}
+}
+
+// loadCircuitBreakerOptions reads the circuit breaker configuration from the environment variables.
+func loadCircuitBreakerOptions() (service.CircuitBreakerOptions, error) {
+	var options service.CircuitBreakerOptions
+	var err error
+
+	if options.FailureThreshold, err = getEnvInt("TAX_CALCULATOR_BREAKER_FAILURE_THRESHOLD"); err != nil {
+		return options, err
+	}
+	if options.CoolDown, err = getEnvDuration("TAX_CALCULATOR_BREAKER_COOL_DOWN"); err != nil {
+		return options, err
+	}
+
+	return options, nil
+}
+
+// loadWarmUpOptions reads the startup warm-up configuration from the environment variables.
+func loadWarmUpOptions() (service.WarmUpOptions, error) {
+	var options service.WarmUpOptions
+	var err error
+
+	if options.Timeout, err = getEnvDuration("WARM_UP_TIMEOUT"); err != nil {
+		return options, err
+	}
+	if options.RetryInterval, err = getEnvDuration("WARM_UP_RETRY_INTERVAL"); err != nil {
+		return options, err
+	}
+
+	return options, nil
+}
+
+// loadBracketSources orders the available bracket sources by the TAX_BRACKET_SOURCES environment variable, a comma
+// separated list of source names each optionally followed by a timeout, e.g. "remote:3s,remote-secondary:3s,embedded".
+// Without it, the files come first, then the tax calculator APIs and the embedded tables last, or only the embedded
+// tables when TAX_BRACKET_SOURCE is embedded.
+func loadBracketSources(available map[string]service.ITaxBracketService) ([]service.BracketSource, error) {
+	order := os.Getenv("TAX_BRACKET_SOURCES")
+	if order == "" {
+		order = strings.Join([]string{
+			service.TaxBracketSourceFile,
+			service.TaxBracketSourceRemote,
+			service.TaxBracketSourceRemoteSecondary,
+			service.TaxBracketSourceEmbedded,
+		}, ",")
+		if os.Getenv("TAX_BRACKET_SOURCE") == service.TaxBracketSourceEmbedded {
+			order = service.TaxBracketSourceEmbedded
+		}
+
+		// The default order leaves out the sources that are not configured
+		var configured []string
+		for _, name := range strings.Split(order, ",") {
+			if _, ok := available[name]; ok {
+				configured = append(configured, name)
+			}
+		}
+		order = strings.Join(configured, ",")
+	}
+
+	var sources []service.BracketSource
+	seen := make(map[string]bool)
+	for _, field := range strings.Split(order, ",") {
+		name, timeoutStr, _ := strings.Cut(strings.TrimSpace(field), ":")
+		bracketService, ok := available[name]
+		if !ok {
+			return nil, fmt.Errorf("invalid TAX_BRACKET_SOURCES: %q is not a configured bracket source", name)
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("invalid TAX_BRACKET_SOURCES: %q is listed more than once", name)
+		}
+		seen[name] = true
+
+		source := service.BracketSource{Name: name, Service: bracketService}
+		if timeoutStr != "" {
+			timeout, err := time.ParseDuration(timeoutStr)
+			if err != nil {
+				return nil, fmt.Errorf("invalid TAX_BRACKET_SOURCES timeout of %s: %w", name, err)
+			}
+			source.Timeout = timeout
+		}
+		sources = append(sources, source)
+	}
+
+	return sources, nil
+}
+
+// bracketSourceNames lists the names of the bracket sources for the logs.
+func bracketSourceNames(sources []service.BracketSource) string {
+	var names []string
+	for _, source := range sources {
+		names = append(names, source.Name)
+	}
+	return strings.Join(names, ", ")
+}
+
+// loadSourceChainOptions reads the health configuration of the bracket sources from the environment variables.
+func loadSourceChainOptions() (service.SourceChainOptions, error) {
+	var options service.SourceChainOptions
+	var err error
+
+	if options.FailureThreshold, err = getEnvInt("TAX_BRACKET_SOURCE_FAILURE_THRESHOLD"); err != nil {
+		return options, err
+	}
+	if options.RetryAfter, err = getEnvDuration("TAX_BRACKET_SOURCE_RETRY_AFTER"); err != nil {
+		return options, err
+	}
+
+	return options, nil
+}
+
+// loadCacheOptions reads the tax bracket cache configuration from the environment variables.
+func loadCacheOptions() (service.CacheOptions, error) {
+	var options service.CacheOptions
+	var err error
+
+	if options.TTL, err = getEnvDuration("TAX_BRACKET_CACHE_TTL"); err != nil {
+		return options, err
+	}
+	if options.ClosedYearTTL, err = getEnvDuration("TAX_BRACKET_CACHE_CLOSED_YEAR_TTL"); err != nil {
+		return options, err
+	}
+	if options.MaxStaleness, err = getEnvDuration("TAX_BRACKET_CACHE_MAX_STALENESS"); err != nil {
+		return options, err
+	}
+
+	return options, nil
+}
+
+// loadAdminTokens reads the bearer tokens of the admin API from the ADMIN_API_TOKENS environment variable, a comma
+// separated list of name:token pairs. The name is recorded in the audit log of the changes made with the token.
+func loadAdminTokens() (map[string]string, error) {
+	tokens := make(map[string]string)
+	for _, pair := range strings.Split(os.Getenv("ADMIN_API_TOKENS"), ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+
+		name, token, ok := strings.Cut(pair, ":")
+		if !ok || name == "" || token == "" {
+			return nil, errors.New("invalid ADMIN_API_TOKENS entry, expected name:token")
+		}
+		tokens[token] = name
+	}
+
+	return tokens, nil
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// getEnvDuration reads a duration such as "5s" from the environment variable, or zero when it is not set.
+func getEnvDuration(name string) (time.Duration, error) {
+	value := os.Getenv(name)
+	if value == "" {
+		return 0, nil
+	}
+
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s: %w", name, err)
+	}
+
+	return duration, nil
+}
+
+// getEnvInt reads a non-negative integer from the environment variable, or zero when it is not set.
+func getEnvInt(name string) (int, error) {
+	value := os.Getenv(name)
+	if value == "" {
+		return 0, nil
+	}
+
+	number, err := strconv.Atoi(value)
+	if err != nil || number < 0 {
+		return 0, fmt.Errorf("invalid %s: %q is not a non-negative integer", name, value)
+	}
+
+	return number, nil
+}
+
+// getEnvFloats reads a comma separated list of non-negative numbers from the environment variable, or nil when it is
+// not set.
+func getEnvFloats(name string) ([]float64, error) {
+	value := os.Getenv(name)
+	if value == "" {
+		return nil, nil
+	}
+
+	var numbers []float64
+	for _, field := range strings.Split(value, ",") {
+		number, err := strconv.ParseFloat(strings.TrimSpace(field), 64)
+		if err != nil || number < 0 {
+			return nil, fmt.Errorf("invalid %s: %q is not a non-negative number", name, field)
+		}
+		numbers = append(numbers, number)
+	}
+
+	return numbers, nil
+}
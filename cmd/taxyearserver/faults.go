@@ -0,0 +1,120 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// FaultConfig describes the faults injected into the tax-year responses. The zero value injects no faults.
+type FaultConfig struct {
+	// Latency delays every response, e.g. "750ms".
+	Latency string `json:"latency,omitempty"`
+	// ErrorBurst is the number of consecutive requests answered with ErrorStatus.
+	ErrorBurst int `json:"errorBurst,omitempty"`
+	// ErrorEvery starts a new error burst every ErrorEvery requests. With zero, the burst happens once.
+	ErrorEvery int `json:"errorEvery,omitempty"`
+	// ErrorStatus is the status of the error bursts, 503 when not set.
+	ErrorStatus int `json:"errorStatus,omitempty"`
+	// RetryAfter is sent as the Retry-After header of the error bursts when set, e.g. "1".
+	RetryAfter string `json:"retryAfter,omitempty"`
+	// Malformed answers 200 with a truncated JSON body.
+	Malformed bool `json:"malformed,omitempty"`
+	// Timeout never answers, holding the request until the client gives up.
+	Timeout bool `json:"timeout,omitempty"`
+}
+
+// The faults a request can be given.
+const (
+	faultNone      = ""
+	faultError     = "error"
+	faultMalformed = "malformed"
+	faultTimeout   = "timeout"
+)
+
+// validate checks the fault config, returning the parsed latency.
+func (c FaultConfig) validate() (time.Duration, error) {
+	var latency time.Duration
+	if c.Latency != "" {
+		var err error
+		if latency, err = time.ParseDuration(c.Latency); err != nil || latency < 0 {
+			return 0, fmt.Errorf("invalid latency %q", c.Latency)
+		}
+	}
+	if c.ErrorBurst < 0 || c.ErrorEvery < 0 {
+		return 0, errors.New("errorBurst and errorEvery must not be negative")
+	}
+	if c.ErrorEvery > 0 && c.ErrorBurst > c.ErrorEvery {
+		return 0, errors.New("errorBurst must not be longer than errorEvery")
+	}
+	if c.ErrorStatus != 0 && (c.ErrorStatus < 500 || c.ErrorStatus > 599) {
+		return 0, fmt.Errorf("invalid errorStatus %d: must be a 5xx status", c.ErrorStatus)
+	}
+	if c.Malformed && c.Timeout {
+		return 0, errors.New("malformed and timeout cannot both be set")
+	}
+
+	return latency, nil
+}
+
+// faultInjector decides the fault of every tax-year request from the current FaultConfig, which can be switched while
+// the server runs.
+type faultInjector struct {
+	mu       sync.Mutex
+	config   FaultConfig
+	latency  time.Duration
+	requests int
+}
+
+// set replaces the fault config and restarts the error bursts.
+func (f *faultInjector) set(config FaultConfig) error {
+	latency, err := config.validate()
+	if err != nil {
+		return err
+	}
+	if config.ErrorBurst > 0 && config.ErrorStatus == 0 {
+		config.ErrorStatus = http.StatusServiceUnavailable
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.config = config
+	f.latency = latency
+	f.requests = 0
+	return nil
+}
+
+// get returns the current fault config.
+func (f *faultInjector) get() FaultConfig {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.config
+}
+
+// next counts a request and returns the fault it is given, with the latency it is delayed by and the current config.
+// Error bursts take precedence over malformed bodies and timeouts.
+func (f *faultInjector) next() (string, time.Duration, FaultConfig) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	position := f.requests
+	f.requests++
+	if f.config.ErrorEvery > 0 {
+		position %= f.config.ErrorEvery
+	}
+
+	switch {
+	case position < f.config.ErrorBurst:
+		return faultError, f.latency, f.config
+	case f.config.Malformed:
+		return faultMalformed, f.latency, f.config
+	case f.config.Timeout:
+		return faultTimeout, f.latency, f.config
+	default:
+		return faultNone, f.latency, f.config
+	}
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestFaultConfigValidate(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		config  FaultConfig
+		latency time.Duration
+		valid   bool
+	}{
+		{name: "NoFaults", config: FaultConfig{}, valid: true},
+		{name: "Latency", config: FaultConfig{Latency: "250ms"}, latency: 250 * time.Millisecond, valid: true},
+		{name: "InvalidLatency", config: FaultConfig{Latency: "soon"}},
+		{name: "NegativeLatency", config: FaultConfig{Latency: "-1s"}},
+		{name: "RepeatedBurst", config: FaultConfig{ErrorBurst: 2, ErrorEvery: 5, ErrorStatus: 502}, valid: true},
+		{name: "BurstAsLongAsPeriod", config: FaultConfig{ErrorBurst: 3, ErrorEvery: 3}, valid: true},
+		{name: "BurstLongerThanPeriod", config: FaultConfig{ErrorBurst: 4, ErrorEvery: 3}},
+		{name: "NegativeBurst", config: FaultConfig{ErrorBurst: -1}},
+		{name: "NegativePeriod", config: FaultConfig{ErrorEvery: -1}},
+		{name: "ClientErrorStatus", config: FaultConfig{ErrorBurst: 1, ErrorStatus: 404}},
+		{name: "StatusOutOfRange", config: FaultConfig{ErrorBurst: 1, ErrorStatus: 600}},
+		{name: "MalformedAndTimeout", config: FaultConfig{Malformed: true, Timeout: true}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			latency, err := tc.config.validate()
+			if tc.valid && err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if !tc.valid && err == nil {
+				t.Fatalf("Expected %+v to be rejected", tc.config)
+			}
+			if latency != tc.latency {
+				t.Errorf("Expected a latency of %s, but got %s", tc.latency, latency)
+			}
+		})
+	}
+}
+
+func TestFaultInjectorNext(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		config FaultConfig
+		faults []string
+	}{
+		{
+			name:   "NoFaults",
+			config: FaultConfig{},
+			faults: []string{faultNone, faultNone, faultNone},
+		},
+		{
+			name:   "SingleBurst",
+			config: FaultConfig{ErrorBurst: 2},
+			faults: []string{faultError, faultError, faultNone, faultNone, faultNone},
+		},
+		{
+			name:   "RepeatedBurst",
+			config: FaultConfig{ErrorBurst: 2, ErrorEvery: 3},
+			faults: []string{faultError, faultError, faultNone, faultError, faultError, faultNone, faultError},
+		},
+		{
+			name:   "BurstAsLongAsPeriod",
+			config: FaultConfig{ErrorBurst: 2, ErrorEvery: 2},
+			faults: []string{faultError, faultError, faultError, faultError},
+		},
+		{
+			name:   "Malformed",
+			config: FaultConfig{Malformed: true},
+			faults: []string{faultMalformed, faultMalformed},
+		},
+		{
+			name:   "Timeout",
+			config: FaultConfig{Timeout: true},
+			faults: []string{faultTimeout, faultTimeout},
+		},
+		{
+			name:   "BurstBeforeMalformed",
+			config: FaultConfig{ErrorBurst: 1, ErrorEvery: 2, Malformed: true},
+			faults: []string{faultError, faultMalformed, faultError, faultMalformed},
+		},
+		{
+			name:   "BurstBeforeTimeout",
+			config: FaultConfig{ErrorBurst: 1, Timeout: true},
+			faults: []string{faultError, faultTimeout, faultTimeout},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			faults := &faultInjector{}
+			if err := faults.set(tc.config); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			for i, expected := range tc.faults {
+				if fault, _, _ := faults.next(); fault != expected {
+					t.Errorf("Expected request %d to get %q, but got %q", i+1, expected, fault)
+				}
+			}
+		})
+	}
+}
+
+func TestFaultInjectorSet(t *testing.T) {
+	faults := &faultInjector{}
+
+	t.Run("DefaultsErrorStatus", func(t *testing.T) {
+		if err := faults.set(FaultConfig{ErrorBurst: 1, Latency: "10ms"}); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		fault, latency, config := faults.next()
+		if fault != faultError || config.ErrorStatus != http.StatusServiceUnavailable {
+			t.Errorf("Expected a 503 error, but got %q %d", fault, config.ErrorStatus)
+		}
+		if latency != 10*time.Millisecond {
+			t.Errorf("Expected a latency of 10ms, but got %s", latency)
+		}
+	})
+
+	t.Run("RestartsBurst", func(t *testing.T) {
+		if err := faults.set(FaultConfig{ErrorBurst: 1}); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if fault, _, _ := faults.next(); fault != faultError {
+			t.Errorf("Expected the switched burst to start again, but got %q", fault)
+		}
+	})
+
+	t.Run("KeepsConfigOfRejectedSwitch", func(t *testing.T) {
+		if err := faults.set(FaultConfig{ErrorBurst: -1}); err == nil {
+			t.Fatalf("Expected the invalid config to be rejected")
+		}
+		if config := faults.get(); config.ErrorBurst != 1 {
+			t.Errorf("Expected the previous config to be kept, but got %+v", config)
+		}
+	})
+}
@@ -0,0 +1,189 @@
+// Command taxyearserver is a local stand-in for the upstream tax-year service. It serves the bracket tables of a
+// directory on the same /tax-calculator/tax-year/{year} contract, and can inject latency, 5xx bursts, malformed JSON
+// and timeouts to exercise the retry paths of the tax calculator client.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/joho/godotenv"
+)
+
+// defaultTaxYearDir holds the federal tables shipped with the app. It is relative to the root of the repository, so
+// the server has to be started from there unless the directory is given.
+const defaultTaxYearDir = "internal/service/tables/federal"
+
+func main() {
+	logger := log.New(os.Stdout, "[tax-year] ", log.LstdFlags)
+
+	// The .env of the app is optional here, it only provides PORT_TAX_YEAR
+	if err := godotenv.Load(); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		logger.Fatalf("Failed to load .env: %v", err)
+	}
+
+	dir := os.Getenv("TAX_YEAR_DIR")
+	if dir == "" {
+		dir = defaultTaxYearDir
+	}
+	flag.StringVar(&dir, "dir", dir, "directory of the <year>.json bracket tables, overrides TAX_YEAR_DIR")
+	flag.Parse()
+
+	if _, err := os.Stat(dir); err != nil {
+		logger.Fatalf("Failed to open the tax year directory, run from the root of the project or give it with -dir or TAX_YEAR_DIR: %v", err)
+	}
+
+	faults := &faultInjector{}
+	config, err := loadFaultConfig()
+	if err != nil {
+		logger.Fatalf("Failed to load the faults: %v", err)
+	}
+	if err := faults.set(config); err != nil {
+		logger.Fatalf("Invalid faults: %v", err)
+	}
+
+	port := os.Getenv("PORT_TAX_YEAR")
+	if port == "" {
+		port = "7070"
+	}
+
+	router := setupRouter(logger, dir, faults)
+	logger.Printf("Serving the tax years of %s on port %s with faults %+v", dir, port, config)
+	if err := router.Run(":" + port); err != nil {
+		logger.Fatalf("Failed to start server: %v", err)
+	}
+}
+
+// setupRouter creates the router of the tax-year contract and of the /faults endpoint that switches the faults.
+func setupRouter(logger *log.Logger, dir string, faults *faultInjector) *gin.Engine {
+	router := gin.Default()
+
+	router.GET("/tax-calculator/tax-year/:year", func(c *gin.Context) {
+		getTaxYear(c, logger, dir, faults)
+	})
+
+	router.GET("/faults", func(c *gin.Context) {
+		c.JSON(http.StatusOK, faults.get())
+	})
+
+	router.PUT("/faults", func(c *gin.Context) {
+		var config FaultConfig
+		if err := c.ShouldBindJSON(&config); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "Body must be a JSON fault config"})
+			return
+		}
+		if err := faults.set(config); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+			return
+		}
+
+		logger.Printf("Faults switched to %+v", config)
+		c.JSON(http.StatusOK, faults.get())
+	})
+
+	router.DELETE("/faults", func(c *gin.Context) {
+		_ = faults.set(FaultConfig{})
+		logger.Println("Faults cleared")
+		c.JSON(http.StatusOK, faults.get())
+	})
+
+	return router
+}
+
+// getTaxYear serves the bracket table of the year as the upstream does, after applying the fault of the request.
+func getTaxYear(c *gin.Context, logger *log.Logger, dir string, faults *faultInjector) {
+	year := c.Param("year")
+	fault, latency, config := faults.next()
+
+	if latency > 0 {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case <-time.After(latency):
+		}
+	}
+
+	switch fault {
+	case faultError:
+		logger.Printf("Injecting status %d for %s", config.ErrorStatus, year)
+		if config.RetryAfter != "" {
+			c.Header("Retry-After", config.RetryAfter)
+		}
+		c.JSON(config.ErrorStatus, gin.H{"message": "Injected failure"})
+		return
+	case faultTimeout:
+		logger.Printf("Injecting a timeout for %s", year)
+		<-c.Request.Context().Done()
+		return
+	}
+
+	if _, err := strconv.Atoi(year); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"message": "Tax year not found"})
+		return
+	}
+
+	body, err := os.ReadFile(filepath.Join(dir, year+".json"))
+	if errors.Is(err, fs.ErrNotExist) {
+		c.JSON(http.StatusNotFound, gin.H{"message": "Tax year not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	if fault == faultMalformed {
+		logger.Printf("Injecting a malformed body for %s", year)
+		body = body[:len(body)/2]
+	}
+
+	c.Data(http.StatusOK, "application/json", body)
+}
+
+// loadFaultConfig reads the faults injected from startup from the FAULT_* environment variables.
+func loadFaultConfig() (FaultConfig, error) {
+	config := FaultConfig{
+		Latency:    os.Getenv("FAULT_LATENCY"),
+		RetryAfter: os.Getenv("FAULT_RETRY_AFTER"),
+	}
+
+	for name, value := range map[string]*int{
+		"FAULT_ERROR_BURST":  &config.ErrorBurst,
+		"FAULT_ERROR_EVERY":  &config.ErrorEvery,
+		"FAULT_ERROR_STATUS": &config.ErrorStatus,
+	} {
+		if os.Getenv(name) == "" {
+			continue
+		}
+		number, err := strconv.Atoi(os.Getenv(name))
+		if err != nil {
+			return config, fmt.Errorf("invalid %s: %q is not an integer", name, os.Getenv(name))
+		}
+		*value = number
+	}
+
+	for name, value := range map[string]*bool{
+		"FAULT_MALFORMED": &config.Malformed,
+		"FAULT_TIMEOUT":   &config.Timeout,
+	} {
+		if os.Getenv(name) == "" {
+			continue
+		}
+		enabled, err := strconv.ParseBool(os.Getenv(name))
+		if err != nil {
+			return config, fmt.Errorf("invalid %s: %q is not a boolean", name, os.Getenv(name))
+		}
+		*value = enabled
+	}
+
+	return config, nil
+}
@@ -0,0 +1,275 @@
+package controller
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/siparisa/interview-test-server/internal/controller/helper"
+	"github.com/siparisa/interview-test-server/internal/entity"
+	"github.com/siparisa/interview-test-server/internal/service"
+	"io"
+	"strings"
+)
+
+type AdminController struct {
+	bracketOverrideService service.IBracketOverrideService
+	bracketHistoryService  service.IBracketHistoryService
+	taxYearService         service.ITaxYearService
+}
+
+// NewAdminController creates a new instance of AdminController with the given IBracketOverrideService,
+// IBracketHistoryService and ITaxYearService.
+func NewAdminController(
+	bracketOverrideService service.IBracketOverrideService,
+	bracketHistoryService service.IBracketHistoryService,
+	taxYearService service.ITaxYearService,
+) *AdminController {
+	return &AdminController{
+		bracketOverrideService: bracketOverrideService,
+		bracketHistoryService:  bracketHistoryService,
+		taxYearService:         taxYearService,
+	}
+}
+
+// GetBracketOverride @Summary Get bracket override
+// @Description Show the staged and active bracket overrides of a jurisdiction and tax year
+// @ID getBracketOverride
+// @Produce json
+// @Param jurisdiction path string true "Jurisdiction (federal, ON or QC)"
+// @Param year path string true "Tax year (e.g. 2022)"
+// @Success 200 {object} entity.BracketOverrideStatus
+// @Failure 401 {object} APIError
+// @Failure 404 {object} APIError
+// @Router /admin/brackets/{jurisdiction}/{year} [get]
+func (c *AdminController) GetBracketOverride(ctx *gin.Context) {
+	jurisdiction, taxYear, ok := c.getOverrideTable(ctx)
+	if !ok {
+		return
+	}
+
+	status, err := c.bracketOverrideService.GetTaxBracketOverride(jurisdiction, taxYear)
+	if err != nil {
+		handleBracketOverrideError(ctx, err)
+		return
+	}
+
+	helper.OK(ctx, status)
+}
+
+// PublishBracketOverride @Summary Publish bracket override
+// @Description Validate a bracket table and stage it as the next override of a jurisdiction and tax year, effective
+// @Description from the given date or from the start of the year
+// @ID publishBracketOverride
+// @Accept json
+// @Produce json
+// @Param jurisdiction path string true "Jurisdiction (federal, ON or QC)"
+// @Param year path string true "Tax year (e.g. 2022)"
+// @Param body body PublishBracketOverrideBody true "Bracket table"
+// @Success 200 {object} entity.BracketOverride
+// @Failure 400 {object} APIError
+// @Failure 401 {object} APIError
+// @Failure 422 {object} APIError
+// @Router /admin/brackets/{jurisdiction}/{year} [put]
+func (c *AdminController) PublishBracketOverride(ctx *gin.Context) {
+	jurisdiction, taxYear, ok := c.getOverrideTable(ctx)
+	if !ok {
+		return
+	}
+
+	var body helper.PublishBracketOverrideBody
+	if err := ctx.ShouldBindJSON(&body); err != nil {
+		helper.BadRequest(ctx, "Body must be a JSON object with a tax_brackets list")
+		return
+	}
+
+	effectiveFrom, err := helper.IsValidAsOf(body.EffectiveFrom)
+	if err != nil {
+		helper.BadRequest(ctx, "effective_from must be a date formatted as YYYY-MM-DD")
+		return
+	}
+
+	override, err := c.bracketOverrideService.PublishTaxBracketOverride(jurisdiction, taxYear, effectiveFrom, body.TaxBrackets, ctx.GetString(helper.AdminActorKey), body.Reason)
+	if err != nil {
+		handleBracketOverrideError(ctx, err)
+		return
+	}
+
+	helper.OK(ctx, override)
+}
+
+// PreviewBracketOverride @Summary Preview bracket override
+// @Description Report the impact of activating the staged override on the reference or the given salaries, compared to
+// @Description the brackets in effect on the date it takes effect
+// @ID previewBracketOverride
+// @Accept json
+// @Produce json
+// @Param jurisdiction path string true "Jurisdiction (federal, ON or QC)"
+// @Param year path string true "Tax year (e.g. 2022)"
+// @Param body body SalariesBody false "Salaries"
+// @Success 200 {object} entity.BracketImpactReport
+// @Failure 401 {object} APIError
+// @Failure 404 {object} APIError
+// @Router /admin/brackets/{jurisdiction}/{year}/preview [post]
+func (c *AdminController) PreviewBracketOverride(ctx *gin.Context) {
+	jurisdiction, taxYear, ok := c.getOverrideTable(ctx)
+	if !ok {
+		return
+	}
+
+	// The body is optional, the reference salaries are used without one
+	var body helper.SalariesBody
+	if err := ctx.ShouldBindJSON(&body); err != nil && !errors.Is(err, io.EOF) {
+		helper.BadRequest(ctx, "Salaries must be a list of non-negative amounts")
+		return
+	}
+
+	preview, err := c.bracketOverrideService.PreviewTaxBracketOverride(ctx.Request.Context(), jurisdiction, taxYear, body.Salaries)
+	if err != nil {
+		handleBracketOverrideError(ctx, err)
+		return
+	}
+
+	helper.OK(ctx, preview)
+}
+
+// ActivateBracketOverride @Summary Activate bracket override
+// @Description Put the staged override of a jurisdiction and tax year in use
+// @ID activateBracketOverride
+// @Produce json
+// @Param jurisdiction path string true "Jurisdiction (federal, ON or QC)"
+// @Param year path string true "Tax year (e.g. 2022)"
+// @Success 200 {object} entity.BracketOverride
+// @Failure 401 {object} APIError
+// @Failure 404 {object} APIError
+// @Router /admin/brackets/{jurisdiction}/{year}/activate [post]
+func (c *AdminController) ActivateBracketOverride(ctx *gin.Context) {
+	jurisdiction, taxYear, ok := c.getOverrideTable(ctx)
+	if !ok {
+		return
+	}
+
+	override, err := c.bracketOverrideService.ActivateTaxBracketOverride(jurisdiction, taxYear, ctx.GetString(helper.AdminActorKey))
+	if err != nil {
+		handleBracketOverrideError(ctx, err)
+		return
+	}
+
+	helper.OK(ctx, override)
+}
+
+// RollbackBracketOverride @Summary Roll back bracket override
+// @Description Take the active override of a jurisdiction and tax year out of use, returning to the previous one
+// @ID rollbackBracketOverride
+// @Produce json
+// @Param jurisdiction path string true "Jurisdiction (federal, ON or QC)"
+// @Param year path string true "Tax year (e.g. 2022)"
+// @Success 200 {object} entity.BracketOverrideStatus
+// @Failure 401 {object} APIError
+// @Failure 404 {object} APIError
+// @Router /admin/brackets/{jurisdiction}/{year}/rollback [post]
+func (c *AdminController) RollbackBracketOverride(ctx *gin.Context) {
+	jurisdiction, taxYear, ok := c.getOverrideTable(ctx)
+	if !ok {
+		return
+	}
+
+	status, err := c.bracketOverrideService.RollbackTaxBracketOverride(jurisdiction, taxYear, ctx.GetString(helper.AdminActorKey))
+	if err != nil {
+		handleBracketOverrideError(ctx, err)
+		return
+	}
+
+	helper.OK(ctx, status)
+}
+
+// GetBracketImpact @Summary Get bracket impact reports
+// @Description List the impact reports of the bracket tables of a jurisdiction and year replaced by newer ones, or
+// @Description compare two recorded versions given as before and after on the reference or the given salaries
+// @ID getBracketImpact
+// @Produce json
+// @Param jurisdiction path string true "Jurisdiction (federal, ON or QC)"
+// @Param year path string true "Tax year (e.g. 2022)"
+// @Param before query string false "Recorded version to compare from"
+// @Param after query string false "Recorded version to compare to"
+// @Param salaries query string false "Comma separated salaries to compare on"
+// @Success 200 {object} BracketImpactReportsResponse
+// @Failure 400 {object} APIError
+// @Failure 401 {object} APIError
+// @Failure 404 {object} APIError
+// @Router /admin/brackets/{jurisdiction}/{year}/impact [get]
+func (c *AdminController) GetBracketImpact(ctx *gin.Context) {
+	jurisdiction, taxYear, ok := c.getOverrideTable(ctx)
+	if !ok {
+		return
+	}
+	if ctx.Query("before") == "" && ctx.Query("after") == "" {
+		helper.OK(ctx, helper.BracketImpactReportsResponse{Reports: c.bracketHistoryService.GetBracketImpactReports(jurisdiction, taxYear)})
+		return
+	}
+
+	before, err := helper.IsValidBracketVersion(ctx.Query("before"))
+	if err != nil || before == 0 {
+		helper.BadRequest(ctx, "before must be a recorded bracket version")
+		return
+	}
+	after, err := helper.IsValidBracketVersion(ctx.Query("after"))
+	if err != nil || after == 0 {
+		helper.BadRequest(ctx, "after must be a recorded bracket version")
+		return
+	}
+	salaries, err := helper.IsValidSalaries(ctx.Query("salaries"))
+	if err != nil {
+		helper.BadRequest(ctx, err.Error())
+		return
+	}
+
+	report, err := c.bracketHistoryService.CompareTaxBracketVersions(jurisdiction, taxYear, before, after, salaries)
+	if err != nil {
+		handleTaxBracketError(ctx, err)
+		return
+	}
+
+	helper.OK(ctx, helper.BracketImpactReportsResponse{Reports: []entity.BracketImpactReport{*report}})
+}
+
+// GetBracketAuditLog @Summary Get bracket audit log
+// @Description List every change made to the bracket overrides, oldest first
+// @ID getBracketAuditLog
+// @Produce json
+// @Success 200 {object} BracketAuditLogResponse
+// @Failure 401 {object} APIError
+// @Router /admin/audit [get]
+func (c *AdminController) GetBracketAuditLog(ctx *gin.Context) {
+	helper.OK(ctx, helper.BracketAuditLogResponse{Entries: c.bracketOverrideService.GetBracketAuditLog()})
+}
+
+// getOverrideTable reads the jurisdiction and tax year of the path, sending Not Found for an unsupported tax year.
+func (c *AdminController) getOverrideTable(ctx *gin.Context) (string, string, bool) {
+	jurisdiction, taxYear := strings.ToLower(ctx.Param("jurisdiction")), ctx.Param("year")
+	if !helper.IsValidTaxYear(taxYear, c.taxYearService.GetSupportedTaxYears()) {
+		helper.NotFound(ctx, "Tax brackets not found for the given year")
+		return "", "", false
+	}
+
+	return jurisdiction, taxYear, true
+}
+
+// handleBracketOverrideError sends the response matching an error returned by the bracket overrides. An invalid table
+// becomes Unprocessable Entity with the problems found.
+func handleBracketOverrideError(ctx *gin.Context, err error) {
+	var validationErr *service.BracketValidationError
+	if errors.As(err, &validationErr) {
+		helper.UnprocessableEntity(ctx, "Invalid tax brackets", strings.Join(validationErr.Problems, "; "))
+		return
+	}
+
+	switch {
+	case errors.Is(err, service.ErrUnsupportedOverrideJurisdiction):
+		helper.NotFound(ctx, "Bracket overrides are only supported for the federal and provincial brackets")
+	case errors.Is(err, service.ErrInvalidOverrideEffectiveDate):
+		helper.BadRequest(ctx, "effective_from must be a date within the tax year")
+	case errors.Is(err, service.ErrBracketOverrideNotFound):
+		helper.NotFound(ctx, "No bracket override to apply this action to")
+	default:
+		handleTaxBracketError(ctx, err)
+	}
+}
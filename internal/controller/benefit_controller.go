@@ -0,0 +1,102 @@
+package controller
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"github.com/siparisa/interview-test-server/internal/controller/helper"
+	"github.com/siparisa/interview-test-server/internal/entity"
+	"github.com/siparisa/interview-test-server/internal/service"
+)
+
+type BenefitController struct {
+	benefitService service.IBenefitService
+}
+
+// NewBenefitController creates a new instance of BenefitController with the given IBenefitService.
+func NewBenefitController(benefitService service.IBenefitService) *BenefitController {
+	return &BenefitController{
+		benefitService: benefitService,
+	}
+}
+
+// GetBenefits @Summary Get estimated benefits
+// @Description Estimate the Canada Child Benefit and GST/HST credit based on family income and children
+// @ID getBenefits
+// @Accept json
+// @Produce json
+// @Param salary query string true "Salary"
+// @Param spouseSalary query string false "Spouse or Common-law Partner Salary"
+// @Param year query string true "Tax Year"
+// @Param maritalStatus query string true "Marital Status (single, married or common-law)"
+// @Param childrenUnder6 query int false "Number of Children Under 6"
+// @Param children6To17 query int false "Number of Children Aged 6 to 17"
+// @Success 200 {object} BenefitEstimateResponse
+// @Failure 400 {object} APIError
+// @Router /benefits [get]
+func (c *BenefitController) GetBenefits(ctx *gin.Context) {
+	var qp helper.GetBenefitsParams
+	if err := ctx.ShouldBindQuery(&qp); err != nil {
+		if ve, ok := err.(validator.ValidationErrors); ok {
+			errorMsg := helper.GetValidationErrorMessage(ve)
+			helper.BadRequest(ctx, errorMsg)
+			return
+		}
+
+		helper.BadRequest(ctx, "Invalid query parameters")
+		return
+	}
+
+	// Validate the positive salary inputs
+	salary, err := helper.IsValidSalary(ctx, qp.Salary)
+	if err != nil {
+		helper.BadRequest(ctx, err.Error())
+		return
+	}
+
+	spouseSalary, err := helper.IsValidCreditAmount(qp.SpouseSalary, "spouse salary")
+	if err != nil {
+		helper.BadRequest(ctx, err.Error())
+		return
+	}
+
+	if spouseSalary > 0 && qp.MaritalStatus == service.MaritalStatusSingle {
+		helper.BadRequest(ctx, "Spouse salary requires a married or common-law marital status.")
+		return
+	}
+
+	// Validate the valid tax year input
+	if !helper.IsValidTaxYear(qp.Year, c.benefitService.GetBenefitTaxYears()) {
+		helper.BadRequest(ctx, "Invalid tax year. Please select a valid tax year.")
+		return
+	}
+
+	// Calculate the family net income from the salaries
+	familyNetIncome, err := c.benefitService.CalculateFamilyNetIncome(salary, spouseSalary)
+	if err != nil {
+		helper.InternalServerError(ctx, "Failed to calculate family net income")
+		return
+	}
+
+	// Estimate the benefits for the benefit year
+	estimate, err := c.benefitService.EstimateBenefits(qp.Year, entity.BenefitInputs{
+		FamilyNetIncome: familyNetIncome,
+		MaritalStatus:   qp.MaritalStatus,
+		ChildrenUnder6:  qp.ChildrenUnder6,
+		Children6To17:   qp.Children6To17,
+	})
+	if err != nil {
+		handleCalculationError(ctx, err, "Failed to estimate benefits")
+		return
+	}
+
+	// Prepare the response
+	response := helper.BenefitEstimateResponse{
+		BenefitYear:        estimate.BenefitYear,
+		FamilyNetIncome:    estimate.FamilyNetIncome,
+		CanadaChildBenefit: estimate.CanadaChildBenefit,
+		GSTHSTCredit:       estimate.GSTHSTCredit,
+		TotalBenefitAmount: estimate.TotalBenefitAmount,
+	}
+
+	helper.OK(ctx, response)
+}
@@ -0,0 +1,119 @@
+package controller
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/siparisa/interview-test-server/internal/controller/helper"
+	"github.com/siparisa/interview-test-server/internal/entity"
+	"github.com/siparisa/interview-test-server/internal/service"
+	"strings"
+)
+
+type BracketController struct {
+	taxBracketService    service.IBracketHistoryService
+	taxYearService       service.ITaxYearService
+	provincialTaxService service.IProvincialTaxService
+}
+
+// NewBracketController creates a new instance of BracketController with the given IBracketHistoryService,
+// ITaxYearService and IProvincialTaxService.
+func NewBracketController(
+	taxBracketService service.IBracketHistoryService,
+	taxYearService service.ITaxYearService,
+	provincialTaxService service.IProvincialTaxService,
+) *BracketController {
+	return &BracketController{
+		taxBracketService:    taxBracketService,
+		taxYearService:       taxYearService,
+		provincialTaxService: provincialTaxService,
+	}
+}
+
+// GetTaxBrackets @Summary Get tax brackets
+// @Description Show the normalized tax brackets the calculations use for a year, with their source and fetch time
+// @ID getTaxBrackets
+// @Produce json
+// @Param table path string true "Tax year (e.g. 2022), or jurisdiction and tax year (e.g. ON/2022)"
+// @Param asOf query string false "Date the brackets are effective on (YYYY-MM-DD)"
+// @Param version query string false "Recorded version of the brackets"
+// @Param history query bool false "Include every recorded version of the brackets"
+// @Success 200 {object} TaxBracketsResponse
+// @Failure 404 {object} APIError
+// @Router /brackets/{table} [get]
+func (c *BracketController) GetTaxBrackets(ctx *gin.Context) {
+	// The table is either /<year> for the federal brackets or /<jurisdiction>/<year>
+	parts := strings.Split(strings.Trim(ctx.Param("table"), "/"), "/")
+	jurisdiction, taxYear := service.FederalJurisdiction, parts[0]
+	if len(parts) == 2 {
+		jurisdiction, taxYear = strings.ToLower(parts[0]), parts[1]
+	} else if len(parts) > 2 {
+		helper.NotFound(ctx, "Tax brackets not found")
+		return
+	}
+
+	if !helper.IsValidTaxYear(taxYear, c.taxYearService.GetSupportedTaxYears()) {
+		helper.NotFound(ctx, "Tax brackets not found for the given year")
+		return
+	}
+
+	asOf, err := helper.IsValidAsOf(ctx.Query("asOf"))
+	if err != nil {
+		helper.BadRequest(ctx, err.Error())
+		return
+	}
+
+	version, err := helper.IsValidBracketVersion(ctx.Query("version"))
+	if err != nil {
+		helper.BadRequest(ctx, err.Error())
+		return
+	}
+
+	bracketCtx := ctx.Request.Context()
+	if !asOf.IsZero() {
+		bracketCtx = service.WithAsOf(bracketCtx, asOf)
+	}
+	if version > 0 {
+		bracketCtx = service.WithBracketVersion(bracketCtx, version)
+	}
+
+	// Retrieve the brackets the same way the calculations do
+	var taxBrackets *entity.TaxBrackets
+	if jurisdiction == service.FederalJurisdiction {
+		taxBrackets, err = c.taxBracketService.GetTaxBracket(bracketCtx, taxYear)
+		if err != nil {
+			handleTaxBracketError(ctx, err)
+			return
+		}
+	} else {
+		jurisdiction = strings.ToUpper(jurisdiction)
+		taxBrackets, err = c.provincialTaxService.GetProvincialTaxBrackets(bracketCtx, jurisdiction, taxYear)
+		if errors.Is(err, service.ErrUnsupportedProvince) || errors.Is(err, service.ErrTaxYearNotFound) {
+			helper.NotFound(ctx, "Tax brackets not found for the given jurisdiction and year")
+			return
+		}
+		if err != nil {
+			handleTaxBracketError(ctx, err)
+			return
+		}
+	}
+
+	response := helper.TaxBracketsResponse{
+		Jurisdiction: jurisdiction,
+		Year:         taxYear,
+		Source:       taxBrackets.Source,
+		Stale:        taxBrackets.Stale,
+		TaxBrackets:  taxBrackets.TaxBrackets,
+		Version:      taxBrackets.Version,
+	}
+	if !taxBrackets.AsOf.IsZero() {
+		response.AsOf = &taxBrackets.AsOf
+	}
+	if !taxBrackets.EffectiveFrom.IsZero() {
+		response.EffectiveFrom = &taxBrackets.EffectiveFrom
+	}
+	if ctx.Query("history") == "true" {
+		response.History = c.taxBracketService.GetTaxBracketHistory(jurisdiction, taxYear)
+	}
+
+	helper.OK(ctx, response)
+}
@@ -0,0 +1,64 @@
+package controller
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/siparisa/interview-test-server/internal/controller/helper"
+	"github.com/siparisa/interview-test-server/internal/service"
+	"net/http"
+)
+
+// handleTaxBracketError sends the response matching an error returned while retrieving the tax brackets. A year the
+// upstream does not know becomes Not Found, an open circuit breaker Service Unavailable and any other upstream failure
+// a Bad Gateway with the failure reason.
+func handleTaxBracketError(ctx *gin.Context, err error) {
+	if errors.Is(err, service.ErrCircuitOpen) {
+		helper.ServiceUnavailable(ctx, "Tax bracket service is temporarily unavailable. Please try again later.")
+		return
+	}
+
+	if errors.Is(err, service.ErrTaxYearNotFound) {
+		helper.NotFound(ctx, "Tax brackets not found for the given year")
+		return
+	}
+	if errors.Is(err, service.ErrTaxBracketVersionNotFound) {
+		helper.NotFound(ctx, "Tax bracket version not found for the given year")
+		return
+	}
+
+	var fetchErr *service.BracketFetchError
+	if errors.As(err, &fetchErr) {
+		if fetchErr.StatusCode == http.StatusNotFound {
+			helper.NotFound(ctx, "Tax brackets not found for the given year")
+			return
+		}
+
+		helper.BadGateway(ctx, "Failed to get tax brackets", fetchErr.Error())
+		return
+	}
+
+	helper.UpstreamError(ctx, err, "Failed to get tax brackets")
+}
+
+// handleCalculationError sends the response matching an error returned while calculating the taxes, contributions or
+// benefits of a request. An unsupported province becomes Bad Request, an unknown recorded version of the provincial
+// brackets Not Found, a tax year whose rules are not published Unprocessable Entity and any other failure an Internal
+// Server Error with the given message.
+func handleCalculationError(ctx *gin.Context, err error, message string) {
+	if errors.Is(err, service.ErrUnsupportedProvince) {
+		helper.BadRequest(ctx, "Unsupported province. Please select a supported province.")
+		return
+	}
+
+	if errors.Is(err, service.ErrTaxBracketVersionNotFound) {
+		helper.NotFound(ctx, "Tax bracket version not found for the given year")
+		return
+	}
+
+	if errors.Is(err, service.ErrTaxYearRulesNotFound) || errors.Is(err, service.ErrTaxYearNotFound) {
+		helper.UnprocessableEntity(ctx, "Rules not published for the given tax year", err.Error())
+		return
+	}
+
+	helper.InternalServerError(ctx, message)
+}
@@ -0,0 +1,40 @@
+package helper
+
+import (
+	"crypto/subtle"
+	"github.com/gin-gonic/gin"
+	"strings"
+)
+
+// AdminActorKey is the gin context key the name of the authenticated admin is stored under.
+const AdminActorKey = "adminActor"
+
+// AdminAuth returns a middleware that only lets requests through with a bearer token of one of the admins, given as a
+// map of token to admin name. The name of the admin is stored under AdminActorKey for the audit log. Without any
+// tokens every request is rejected.
+func AdminAuth(tokens map[string]string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		token, ok := strings.CutPrefix(ctx.GetHeader("Authorization"), "Bearer ")
+		if !ok || token == "" {
+			Unauthorized(ctx, "Admin bearer token is required")
+			ctx.Abort()
+			return
+		}
+
+		actor := ""
+		for adminToken, name := range tokens {
+			// Compare every token in constant time so the response time does not reveal a partial match
+			if subtle.ConstantTimeCompare([]byte(token), []byte(adminToken)) == 1 {
+				actor = name
+			}
+		}
+		if actor == "" {
+			Unauthorized(ctx, "Invalid admin bearer token")
+			ctx.Abort()
+			return
+		}
+
+		ctx.Set(AdminActorKey, actor)
+		ctx.Next()
+	}
+}
@@ -4,18 +4,66 @@ import (
 	"fmt"
 	"github.com/gin-gonic/gin"
 	"github.com/go-playground/validator/v10"
+	"github.com/siparisa/interview-test-server/internal/entity"
 	"strconv"
+	"strings"
+	"time"
 )
 
 // GetIncomeTaxParams is  query params for getting salary and year to calculate tax
 type GetIncomeTaxParams struct {
 	Salary string `form:"salary" binding:"required,numeric"`
 	Year   string `form:"year" binding:"required,numeric,len=4"`
+
+	Donations       string `form:"donations" binding:"omitempty,numeric"`
+	MedicalExpenses string `form:"medicalExpenses" binding:"omitempty,numeric"`
+	Province        string `form:"province" binding:"omitempty,alpha,len=2"`
+
+	AsOf                     string `form:"asOf" binding:"omitempty,datetime=2006-01-02"`
+	BracketVersion           string `form:"bracketVersion" binding:"omitempty,numeric"`
+	ProvincialBracketVersion string `form:"provincialBracketVersion" binding:"omitempty,numeric"`
+}
+
+// GetBenefitsParams is query params for getting the family situation to estimate benefits
+type GetBenefitsParams struct {
+	Salary       string `form:"salary" binding:"required,numeric"`
+	SpouseSalary string `form:"spouseSalary" binding:"omitempty,numeric"`
+	Year         string `form:"year" binding:"required,numeric,len=4"`
+
+	MaritalStatus  string `form:"maritalStatus" binding:"required,oneof=single married common-law"`
+	ChildrenUnder6 int    `form:"childrenUnder6" binding:"omitempty,min=0,max=20"`
+	Children6To17  int    `form:"children6To17" binding:"omitempty,min=0,max=20"`
+}
+
+// GetMarginalRateParams is query params for getting the income and family situation to calculate the marginal rate
+type GetMarginalRateParams struct {
+	Salary   string `form:"salary" binding:"required,numeric"`
+	Year     string `form:"year" binding:"required,numeric,len=4"`
+	Delta    string `form:"delta" binding:"omitempty,numeric"`
+	Province string `form:"province" binding:"omitempty,alpha,len=2"`
+
+	SpouseSalary   string `form:"spouseSalary" binding:"omitempty,numeric"`
+	MaritalStatus  string `form:"maritalStatus" binding:"omitempty,oneof=single married common-law"`
+	ChildrenUnder6 int    `form:"childrenUnder6" binding:"omitempty,min=0,max=20"`
+	Children6To17  int    `form:"children6To17" binding:"omitempty,min=0,max=20"`
+	OASIncome      string `form:"oasIncome" binding:"omitempty,numeric"`
+}
+
+// PublishBracketOverrideBody is the body for publishing a bracket table through the admin API
+type PublishBracketOverrideBody struct {
+	TaxBrackets   []entity.TaxBracket `json:"tax_brackets" binding:"required"`
+	Reason        string              `json:"reason"`
+	EffectiveFrom string              `json:"effective_from"`
+}
+
+// SalariesBody is the optional body listing the salaries to report the impact of a bracket table change on
+type SalariesBody struct {
+	Salaries []float64 `json:"salaries" binding:"omitempty,dive,min=0"`
 }
 
 // GetValidationErrorMessage generates the validation error message for the provided validation errors.
 func GetValidationErrorMessage(ve validator.ValidationErrors) string {
-	var errorMsgSalary, errorMsgYear string
+	var errorMsgSalary, errorMsgYear, errorMsgOptional string
 	for _, e := range ve {
 		switch e.Field() {
 		case "Salary":
@@ -38,6 +86,20 @@ func GetValidationErrorMessage(ve validator.ValidationErrors) string {
 			default:
 				errorMsgSalary = "Invalid Year"
 			}
+		case "Donations", "MedicalExpenses", "SpouseSalary", "Delta", "OASIncome", "BracketVersion", "ProvincialBracketVersion":
+			if e.Tag() == "numeric" {
+				errorMsgOptional = e.Field() + " must be a numeric value"
+			} else {
+				errorMsgOptional = "Invalid " + e.Field()
+			}
+		case "AsOf":
+			errorMsgOptional = "AsOf must be a date formatted as YYYY-MM-DD"
+		case "Province":
+			errorMsgOptional = "Province must be a two-letter province code"
+		case "MaritalStatus":
+			errorMsgOptional = "MaritalStatus must be one of single, married or common-law"
+		case "ChildrenUnder6", "Children6To17":
+			errorMsgOptional = e.Field() + " must be a whole number between 0 and 20"
 		default:
 			errorMsgSalary = "Invalid Year and Salary Parameters"
 		}
@@ -45,6 +107,9 @@ func GetValidationErrorMessage(ve validator.ValidationErrors) string {
 
 	// Combine the error messages for Salary and Year
 	errorMsg := errorMsgSalary + "\n" + errorMsgYear
+	if errorMsgOptional != "" {
+		errorMsg += "\n" + errorMsgOptional
+	}
 
 	if errorMsg == "\n" {
 		// If there are no specific error messages, use a generic one
@@ -54,10 +119,9 @@ func GetValidationErrorMessage(ve validator.ValidationErrors) string {
 	return errorMsg
 }
 
-// IsValidTaxYear checks if the provided tax year is valid.
-func IsValidTaxYear(taxYear string) bool {
-	validTaxYears := []string{"2019", "2020", "2021", "2022"}
-	for _, year := range validTaxYears {
+// IsValidTaxYear checks if the provided tax year is one of the supported tax years.
+func IsValidTaxYear(taxYear string, supportedTaxYears []string) bool {
+	for _, year := range supportedTaxYears {
 		if year == taxYear {
 			return true
 		}
@@ -80,3 +144,70 @@ func IsValidSalary(ctx *gin.Context, salaryStr string) (float64, error) {
 
 	return salary, nil
 }
+
+// IsValidCreditAmount checks if the given optional credit amount string is valid (empty or non-negative float64).
+func IsValidCreditAmount(amountStr string, name string) (float64, error) {
+	if amountStr == "" {
+		return 0, nil
+	}
+
+	amount, err := strconv.ParseFloat(amountStr, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s", name)
+	}
+
+	if amount < 0 {
+		return 0, fmt.Errorf("%s cannot be negative", name)
+	}
+
+	return amount, nil
+}
+
+// IsValidAsOf checks if the given optional asOf date string is valid (empty or a YYYY-MM-DD date). An empty date
+// returns the zero time.
+func IsValidAsOf(asOfStr string) (time.Time, error) {
+	if asOfStr == "" {
+		return time.Time{}, nil
+	}
+
+	asOf, err := time.Parse("2006-01-02", asOfStr)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("asOf must be a date formatted as YYYY-MM-DD")
+	}
+
+	return asOf, nil
+}
+
+// IsValidBracketVersion checks if the given optional bracket version string is valid (empty or a positive integer).
+// An empty version returns zero.
+func IsValidBracketVersion(versionStr string) (int, error) {
+	if versionStr == "" {
+		return 0, nil
+	}
+
+	version, err := strconv.Atoi(versionStr)
+	if err != nil || version < 1 {
+		return 0, fmt.Errorf("bracket version must be a positive integer")
+	}
+
+	return version, nil
+}
+
+// IsValidSalaries checks if the given optional comma separated list of salaries is valid (empty or non-negative
+// float64s). An empty list returns nil.
+func IsValidSalaries(salariesStr string) ([]float64, error) {
+	if salariesStr == "" {
+		return nil, nil
+	}
+
+	var salaries []float64
+	for _, salaryStr := range strings.Split(salariesStr, ",") {
+		salary, err := strconv.ParseFloat(strings.TrimSpace(salaryStr), 64)
+		if err != nil || salary < 0 {
+			return nil, fmt.Errorf("salaries must be a comma separated list of non-negative amounts")
+		}
+		salaries = append(salaries, salary)
+	}
+
+	return salaries, nil
+}
@@ -1,15 +1,103 @@
 package helper
 
 import (
+	"context"
+	"errors"
 	"github.com/gin-gonic/gin"
+	"github.com/siparisa/interview-test-server/internal/entity"
 	"net/http"
+	"time"
 )
 
 // TaxAmountResponse represents the response for the calculate-tax endpoint
 type TaxAmountResponse struct {
 	TotalTaxAmount   float64            `json:"totalTaxAmount"`
 	TaxAmountPerBand map[string]float64 `json:"taxAmountPerBand"`
+	BandLabels       map[string]string  `json:"bandLabels,omitempty"`
 	EffectiveRate    float64            `json:"effectiveRate"`
+
+	TaxCredits        []entity.TaxCredit `json:"taxCredits,omitempty"`
+	TotalCreditAmount float64            `json:"totalCreditAmount"`
+
+	Province                  string                       `json:"province,omitempty"`
+	FederalAbatement          float64                      `json:"federalAbatement,omitempty"`
+	ProvincialTax             *entity.ProvincialTaxResult  `json:"provincialTax,omitempty"`
+	PayrollContributions      []entity.PayrollContribution `json:"payrollContributions,omitempty"`
+	TotalPayrollContributions float64                      `json:"totalPayrollContributions,omitempty"`
+	TotalCombinedTaxAmount    float64                      `json:"totalCombinedTaxAmount,omitempty"`
+
+	BracketsAsOf          time.Time `json:"bracketsAsOf"`
+	Stale                 bool      `json:"stale"`
+	BracketSource         string    `json:"bracketSource"`
+	BracketsEffectiveFrom time.Time `json:"bracketsEffectiveFrom"`
+	BracketVersion        int       `json:"bracketVersion,omitempty"`
+}
+
+// BenefitEstimateResponse represents the response for the benefits endpoint
+type BenefitEstimateResponse struct {
+	BenefitYear        string  `json:"benefitYear"`
+	FamilyNetIncome    float64 `json:"familyNetIncome"`
+	CanadaChildBenefit float64 `json:"canadaChildBenefit"`
+	GSTHSTCredit       float64 `json:"gstHstCredit"`
+	TotalBenefitAmount float64 `json:"totalBenefitAmount"`
+}
+
+// MarginalRateResponse represents the response for the marginal-rate endpoint
+type MarginalRateResponse struct {
+	Salary                float64                        `json:"salary"`
+	Delta                 float64                        `json:"delta"`
+	MarginalEffectiveRate float64                        `json:"marginalEffectiveRate"`
+	Components            []entity.MarginalRateComponent `json:"components"`
+	PrimaryDriver         string                         `json:"primaryDriver,omitempty"`
+
+	BracketsAsOf  time.Time `json:"bracketsAsOf"`
+	Stale         bool      `json:"stale"`
+	BracketSource string    `json:"bracketSource"`
+}
+
+// StatusResponse represents the response for the status endpoint
+type StatusResponse struct {
+	Status          string                                 `json:"status"`
+	CircuitBreakers map[string]entity.CircuitBreakerStatus `json:"circuitBreakers,omitempty"`
+	Cache           *entity.CacheStats                     `json:"cache,omitempty"`
+	Sources         []entity.BracketSourceHealth           `json:"sources,omitempty"`
+	Shadow          *entity.ShadowStats                    `json:"shadow,omitempty"`
+	WarmUp          *entity.WarmUpStatus                   `json:"warmUp,omitempty"`
+}
+
+// ReadinessResponse represents the response for the readiness endpoint
+type ReadinessResponse struct {
+	Ready  bool                `json:"ready"`
+	WarmUp entity.WarmUpStatus `json:"warmUp"`
+}
+
+// TaxYearsResponse represents the response for the years endpoint
+type TaxYearsResponse struct {
+	TaxYears []entity.TaxYear `json:"taxYears"`
+}
+
+// TaxBracketsResponse represents the response for the brackets endpoint
+type TaxBracketsResponse struct {
+	Jurisdiction string              `json:"jurisdiction"`
+	Year         string              `json:"year"`
+	Source       string              `json:"source"`
+	AsOf         *time.Time          `json:"asOf,omitempty"`
+	Stale        bool                `json:"stale"`
+	TaxBrackets  []entity.TaxBracket `json:"taxBrackets"`
+
+	EffectiveFrom *time.Time                 `json:"effectiveFrom,omitempty"`
+	Version       int                        `json:"version,omitempty"`
+	History       []entity.TaxBracketVersion `json:"history,omitempty"`
+}
+
+// BracketAuditLogResponse represents the response for the admin audit endpoint
+type BracketAuditLogResponse struct {
+	Entries []entity.BracketAuditEntry `json:"entries"`
+}
+
+// BracketImpactReportsResponse represents the response for the admin impact endpoint
+type BracketImpactReportsResponse struct {
+	Reports []entity.BracketImpactReport `json:"reports"`
 }
 
 // APIError represents the JSON response for API errors
@@ -27,6 +115,25 @@ func BadRequest(ctx *gin.Context, message string) {
 	})
 }
 
+// Unauthorized sends an Unauthorized response with the provided error message, asking for a bearer token.
+func Unauthorized(ctx *gin.Context, message string) {
+	ctx.Header("WWW-Authenticate", "Bearer")
+	ctx.JSON(http.StatusUnauthorized, APIError{
+		Code:    http.StatusUnauthorized,
+		Message: message,
+	})
+}
+
+// UnprocessableEntity sends an Unprocessable Entity response with the provided error message and details of the
+// problems found.
+func UnprocessableEntity(ctx *gin.Context, message string, details string) {
+	ctx.JSON(http.StatusUnprocessableEntity, APIError{
+		Code:    http.StatusUnprocessableEntity,
+		Message: message,
+		Details: details,
+	})
+}
+
 // InternalServerError sends an Internal Server Error response with the provided error message.
 func InternalServerError(ctx *gin.Context, message string) {
 	ctx.JSON(http.StatusInternalServerError, APIError{
@@ -35,7 +142,70 @@ func InternalServerError(ctx *gin.Context, message string) {
 	})
 }
 
+// NotFound sends a Not Found response with the provided error message.
+func NotFound(ctx *gin.Context, message string) {
+	ctx.JSON(http.StatusNotFound, APIError{
+		Code:    http.StatusNotFound,
+		Message: message,
+	})
+}
+
+// BadGateway sends a Bad Gateway response with the provided error message and details of the upstream failure.
+func BadGateway(ctx *gin.Context, message string, details string) {
+	ctx.JSON(http.StatusBadGateway, APIError{
+		Code:    http.StatusBadGateway,
+		Message: message,
+		Details: details,
+	})
+}
+
+// ServiceUnavailable sends a Service Unavailable response with the provided error message.
+func ServiceUnavailable(ctx *gin.Context, message string) {
+	ctx.JSON(http.StatusServiceUnavailable, APIError{
+		Code:    http.StatusServiceUnavailable,
+		Message: message,
+	})
+}
+
+// StatusClientClosedRequest is the non-standard status used when the client closed the request before the response.
+const StatusClientClosedRequest = 499
+
+// ClientClosedRequest sends a Client Closed Request response with the provided error message.
+func ClientClosedRequest(ctx *gin.Context, message string) {
+	ctx.JSON(StatusClientClosedRequest, APIError{
+		Code:    StatusClientClosedRequest,
+		Message: message,
+	})
+}
+
+// GatewayTimeout sends a Gateway Timeout response with the provided error message.
+func GatewayTimeout(ctx *gin.Context, message string) {
+	ctx.JSON(http.StatusGatewayTimeout, APIError{
+		Code:    http.StatusGatewayTimeout,
+		Message: message,
+	})
+}
+
+// UpstreamError sends the response matching an error returned while calling an upstream service. Cancelled requests
+// become Client Closed Request, expired deadlines become Gateway Timeout, and any other error an Internal Server Error
+// with the provided message.
+func UpstreamError(ctx *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, context.Canceled):
+		ClientClosedRequest(ctx, "Request cancelled by the client")
+	case errors.Is(err, context.DeadlineExceeded):
+		GatewayTimeout(ctx, "Timed out waiting for the upstream service")
+	default:
+		InternalServerError(ctx, message)
+	}
+}
+
 // OK sends a success response with the provided data.
 func OK(ctx *gin.Context, data interface{}) {
 	ctx.JSON(http.StatusOK, data)
 }
+
+// NotReady sends a Service Unavailable response with the provided data, for probes that check the service is ready.
+func NotReady(ctx *gin.Context, data interface{}) {
+	ctx.JSON(http.StatusServiceUnavailable, data)
+}
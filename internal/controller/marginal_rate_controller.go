@@ -0,0 +1,144 @@
+package controller
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"github.com/siparisa/interview-test-server/internal/controller/helper"
+	"github.com/siparisa/interview-test-server/internal/entity"
+	"github.com/siparisa/interview-test-server/internal/service"
+	"strings"
+)
+
+// defaultMarginalRateDelta is the income increase used when no delta is requested.
+const defaultMarginalRateDelta = 1000
+
+type MarginalRateController struct {
+	taxBracketService   service.ITaxBracketService
+	taxYearService      service.ITaxYearService
+	marginalRateService service.IMarginalRateService
+}
+
+// NewMarginalRateController creates a new instance of MarginalRateController with the given ITaxBracketService,
+// ITaxYearService and IMarginalRateService.
+func NewMarginalRateController(
+	taxBracketService service.ITaxBracketService,
+	taxYearService service.ITaxYearService,
+	marginalRateService service.IMarginalRateService,
+) *MarginalRateController {
+	return &MarginalRateController{
+		taxBracketService:   taxBracketService,
+		taxYearService:      taxYearService,
+		marginalRateService: marginalRateService,
+	}
+}
+
+// GetMarginalEffectiveRate @Summary Get marginal effective tax rate
+// @Description Calculate the combined change in tax, payroll contributions and benefits per dollar earned
+// @ID getMarginalEffectiveRate
+// @Accept json
+// @Produce json
+// @Param salary query string true "Salary"
+// @Param year query string true "Tax Year"
+// @Param delta query string false "Income Increase (default 1000)"
+// @Param province query string false "Province of residence (ON or QC)"
+// @Param spouseSalary query string false "Spouse or Common-law Partner Salary"
+// @Param maritalStatus query string false "Marital Status (single, married or common-law)"
+// @Param childrenUnder6 query int false "Number of Children Under 6"
+// @Param children6To17 query int false "Number of Children Aged 6 to 17"
+// @Param oasIncome query string false "Old Age Security Received"
+// @Success 200 {object} MarginalRateResponse
+// @Failure 400 {object} APIError
+// @Router /marginal-rate [get]
+func (c *MarginalRateController) GetMarginalEffectiveRate(ctx *gin.Context) {
+	var qp helper.GetMarginalRateParams
+	if err := ctx.ShouldBindQuery(&qp); err != nil {
+		if ve, ok := err.(validator.ValidationErrors); ok {
+			errorMsg := helper.GetValidationErrorMessage(ve)
+			helper.BadRequest(ctx, errorMsg)
+			return
+		}
+
+		helper.BadRequest(ctx, "Invalid query parameters")
+		return
+	}
+
+	// Validate the positive salary and amount inputs
+	salary, err := helper.IsValidSalary(ctx, qp.Salary)
+	if err != nil {
+		helper.BadRequest(ctx, err.Error())
+		return
+	}
+
+	spouseSalary, err := helper.IsValidCreditAmount(qp.SpouseSalary, "spouse salary")
+	if err != nil {
+		helper.BadRequest(ctx, err.Error())
+		return
+	}
+
+	if spouseSalary > 0 && qp.MaritalStatus == service.MaritalStatusSingle {
+		helper.BadRequest(ctx, "Spouse salary requires a married or common-law marital status.")
+		return
+	}
+
+	oasIncome, err := helper.IsValidCreditAmount(qp.OASIncome, "OAS income")
+	if err != nil {
+		helper.BadRequest(ctx, err.Error())
+		return
+	}
+
+	delta, err := helper.IsValidCreditAmount(qp.Delta, "delta")
+	if err != nil {
+		helper.BadRequest(ctx, err.Error())
+		return
+	}
+	if qp.Delta == "" {
+		delta = defaultMarginalRateDelta
+	}
+	if delta == 0 {
+		helper.BadRequest(ctx, "delta must be greater than zero")
+		return
+	}
+
+	// Validate the valid tax year input
+	if !helper.IsValidTaxYear(qp.Year, c.taxYearService.GetSupportedTaxYears()) {
+		helper.BadRequest(ctx, "Invalid tax year. Please select a valid tax year.")
+		return
+	}
+
+	// Retrieve the tax brackets for the given year
+	taxBrackets, err := c.taxBracketService.GetTaxBracket(ctx.Request.Context(), qp.Year)
+	if err != nil {
+		handleTaxBracketError(ctx, err)
+		return
+	}
+
+	// Calculate the marginal effective tax rate
+	result, err := c.marginalRateService.CalculateMarginalEffectiveRate(ctx.Request.Context(), taxBrackets, qp.Year, entity.MarginalRateInputs{
+		Salary:         salary,
+		Delta:          delta,
+		Province:       strings.ToUpper(qp.Province),
+		SpouseSalary:   spouseSalary,
+		MaritalStatus:  qp.MaritalStatus,
+		ChildrenUnder6: qp.ChildrenUnder6,
+		Children6To17:  qp.Children6To17,
+		OASIncome:      oasIncome,
+	})
+	if err != nil {
+		handleCalculationError(ctx, err, "Failed to calculate marginal effective rate")
+		return
+	}
+
+	// Prepare the response
+	response := helper.MarginalRateResponse{
+		Salary:                result.Salary,
+		Delta:                 result.Delta,
+		MarginalEffectiveRate: result.MarginalEffectiveRate,
+		Components:            result.Components,
+		PrimaryDriver:         result.PrimaryDriver,
+		BracketsAsOf:          taxBrackets.AsOf,
+		Stale:                 taxBrackets.Stale,
+		BracketSource:         taxBrackets.Source,
+	}
+
+	helper.OK(ctx, response)
+}
@@ -0,0 +1,101 @@
+package controller
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/siparisa/interview-test-server/internal/controller/helper"
+	"github.com/siparisa/interview-test-server/internal/entity"
+	"github.com/siparisa/interview-test-server/internal/service"
+)
+
+const (
+	statusOK       = "ok"
+	statusDegraded = "degraded"
+)
+
+type StatusController struct {
+	circuitBreakerServices map[string]service.ICircuitBreakerTaxBracketService
+	cachedService          service.ICachedTaxBracketService
+	sourceChainService     service.ISourceChainTaxBracketService
+	shadowService          service.IShadowTaxBracketService
+	warmUpService          service.IWarmUpService
+}
+
+// NewStatusController creates a new instance of StatusController with the given ICircuitBreakerTaxBracketService of
+// every remote source by name, ICachedTaxBracketService, ISourceChainTaxBracketService, IShadowTaxBracketService and
+// IWarmUpService. The shadow service is nil when no shadow source is configured.
+func NewStatusController(
+	circuitBreakerServices map[string]service.ICircuitBreakerTaxBracketService,
+	cachedService service.ICachedTaxBracketService,
+	sourceChainService service.ISourceChainTaxBracketService,
+	shadowService service.IShadowTaxBracketService,
+	warmUpService service.IWarmUpService,
+) *StatusController {
+	return &StatusController{
+		circuitBreakerServices: circuitBreakerServices,
+		cachedService:          cachedService,
+		sourceChainService:     sourceChainService,
+		shadowService:          shadowService,
+		warmUpService:          warmUpService,
+	}
+}
+
+// GetStatus @Summary Get service status
+// @Description Report the state of the circuit breakers around the tax bracket services, the bracket cache counts and
+// @Description the health of the bracket sources, the cross-check against the shadow source and the startup warm-up
+// @ID getStatus
+// @Produce json
+// @Success 200 {object} StatusResponse
+// @Router /status [get]
+func (c *StatusController) GetStatus(ctx *gin.Context) {
+	cache := c.cachedService.GetCacheStats()
+	warmUp := c.warmUpService.GetWarmUpStatus()
+
+	response := helper.StatusResponse{
+		Status:          statusOK,
+		CircuitBreakers: make(map[string]entity.CircuitBreakerStatus, len(c.circuitBreakerServices)),
+		Cache:           &cache,
+		Sources:         c.sourceChainService.GetSourceHealth(),
+		WarmUp:          &warmUp,
+	}
+	for name, circuitBreakerService := range c.circuitBreakerServices {
+		circuitBreaker := circuitBreakerService.GetCircuitBreakerStatus()
+		response.CircuitBreakers[name] = circuitBreaker
+		if circuitBreaker.State != service.CircuitClosed {
+			response.Status = statusDegraded
+		}
+	}
+	if c.shadowService != nil {
+		shadow := c.shadowService.GetShadowStats()
+		response.Shadow = &shadow
+	}
+	for _, source := range response.Sources {
+		if !source.Healthy {
+			response.Status = statusDegraded
+		}
+	}
+
+	helper.OK(ctx, response)
+}
+
+// GetReadiness @Summary Get readiness
+// @Description Report whether the service is ready for traffic, which it is once the brackets of the supported tax
+// @Description years were pre-fetched at startup or the warm-up timed out
+// @ID getReadiness
+// @Produce json
+// @Success 200 {object} ReadinessResponse
+// @Failure 503 {object} ReadinessResponse
+// @Router /ready [get]
+func (c *StatusController) GetReadiness(ctx *gin.Context) {
+	warmUp := c.warmUpService.GetWarmUpStatus()
+	response := helper.ReadinessResponse{
+		Ready:  warmUp.Ready,
+		WarmUp: warmUp,
+	}
+
+	if !response.Ready {
+		helper.NotReady(ctx, response)
+		return
+	}
+
+	helper.OK(ctx, response)
+}
@@ -3,22 +3,39 @@ package controller
 import (
 	"github.com/gin-gonic/gin"
 	"github.com/go-playground/validator/v10"
+	"github.com/shopspring/decimal"
 	"github.com/siparisa/interview-test-server/internal/controller/helper"
-	_ "github.com/siparisa/interview-test-server/internal/entity"
+	"github.com/siparisa/interview-test-server/internal/entity"
 	"github.com/siparisa/interview-test-server/internal/service"
-	"time"
+	"strings"
 )
 
 type TaxController struct {
-	taxService        service.ITaxService
-	taxBracketService service.ITaxBracketService
+	taxService                 service.ITaxService
+	taxBracketService          service.ITaxBracketService
+	taxYearService             service.ITaxYearService
+	taxCreditService           service.ITaxCreditService
+	provincialTaxService       service.IProvincialTaxService
+	payrollContributionService service.IPayrollContributionService
 }
 
-// NewTaxController creates a new instance of TaxController with the given ITaxService and ITaxBracketService.
-func NewTaxController(taxService service.ITaxService, taxBracketService service.ITaxBracketService) *TaxController {
+// NewTaxController creates a new instance of TaxController with the given federal, provincial and payroll services.
+// The supported tax years come from the given ITaxYearService.
+func NewTaxController(
+	taxService service.ITaxService,
+	taxBracketService service.ITaxBracketService,
+	taxYearService service.ITaxYearService,
+	taxCreditService service.ITaxCreditService,
+	provincialTaxService service.IProvincialTaxService,
+	payrollContributionService service.IPayrollContributionService,
+) *TaxController {
 	return &TaxController{
-		taxService:        taxService,
-		taxBracketService: taxBracketService,
+		taxService:                 taxService,
+		taxBracketService:          taxBracketService,
+		taxYearService:             taxYearService,
+		taxCreditService:           taxCreditService,
+		provincialTaxService:       provincialTaxService,
+		payrollContributionService: payrollContributionService,
 	}
 }
 
@@ -29,6 +46,12 @@ func NewTaxController(taxService service.ITaxService, taxBracketService service.
 // @Produce json
 // @Param salary query string true "Salary"
 // @Param year query string true "Tax Year"
+// @Param donations query string false "Charitable Donations"
+// @Param medicalExpenses query string false "Medical Expenses"
+// @Param province query string false "Province of residence (e.g. QC)"
+// @Param asOf query string false "Date the brackets are effective on (YYYY-MM-DD)"
+// @Param bracketVersion query string false "Recorded version of the federal brackets"
+// @Param provincialBracketVersion query string false "Recorded version of the provincial brackets"
 // @Success 200 {object} TaxAmountResponse
 // @Failure 400 {object} APIError
 // @Router /calculate-tax [get]
@@ -56,15 +79,62 @@ func (c *TaxController) GetTotalIncomeTax(ctx *gin.Context) {
 	}
 
 	// Validate the valid tax year input
-	if !helper.IsValidTaxYear(taxYear) {
+	if !helper.IsValidTaxYear(taxYear, c.taxYearService.GetSupportedTaxYears()) {
 		helper.BadRequest(ctx, "Invalid tax year. Please select a valid tax year.")
 		return
 	}
 
+	// Validate the optional credit amounts
+	donations, err := helper.IsValidCreditAmount(qp.Donations, "donations")
+	if err != nil {
+		helper.BadRequest(ctx, err.Error())
+		return
+	}
+
+	medicalExpenses, err := helper.IsValidCreditAmount(qp.MedicalExpenses, "medical expenses")
+	if err != nil {
+		helper.BadRequest(ctx, err.Error())
+		return
+	}
+
+	province := strings.ToUpper(qp.Province)
+
+	// Validate the optional effective date and recorded versions of the brackets
+	asOf, err := helper.IsValidAsOf(qp.AsOf)
+	if err != nil {
+		helper.BadRequest(ctx, err.Error())
+		return
+	}
+
+	bracketVersion, err := helper.IsValidBracketVersion(qp.BracketVersion)
+	if err != nil {
+		helper.BadRequest(ctx, err.Error())
+		return
+	}
+
+	provincialBracketVersion, err := helper.IsValidBracketVersion(qp.ProvincialBracketVersion)
+	if err != nil {
+		helper.BadRequest(ctx, err.Error())
+		return
+	}
+
+	// The federal and provincial brackets are effective on the same date, their versions are numbered separately
+	bracketCtx := ctx.Request.Context()
+	if !asOf.IsZero() {
+		bracketCtx = service.WithAsOf(bracketCtx, asOf)
+	}
+	provincialBracketCtx := bracketCtx
+	if bracketVersion > 0 {
+		bracketCtx = service.WithBracketVersion(bracketCtx, bracketVersion)
+	}
+	if provincialBracketVersion > 0 {
+		provincialBracketCtx = service.WithBracketVersion(provincialBracketCtx, provincialBracketVersion)
+	}
+
 	// Retrieve the tax brackets for the given year
-	taxBrackets, err := c.taxBracketService.GetTaxBracket(taxYear, 3, time.Second)
+	taxBrackets, err := c.taxBracketService.GetTaxBracket(bracketCtx, taxYear)
 	if err != nil {
-		helper.InternalServerError(ctx, "Failed to get tax brackets")
+		handleTaxBracketError(ctx, err)
 		return
 	}
 
@@ -82,19 +152,88 @@ func (c *TaxController) GetTotalIncomeTax(ctx *gin.Context) {
 		return
 	}
 
-	// Calculate the effective tax rate
-	effectiveRate, err := c.taxService.CalculateEffectiveRate(totalTaxSalary, salary)
+	// Calculate the non-refundable credits and apply them to the total tax salary
+	taxCredits, err := c.taxCreditService.CalculateTaxCredits(taxYear, taxBrackets, salary, entity.TaxCreditInputs{
+		Donations:       donations,
+		MedicalExpenses: medicalExpenses,
+	})
 	if err != nil {
-		helper.InternalServerError(ctx, "Failed to calculate Effective Rate")
+		handleCalculationError(ctx, err, "Failed to calculate tax credits")
+		return
+	}
+
+	totalTaxSalary, err = c.taxCreditService.ApplyTaxCredits(totalTaxSalary, taxCredits)
+	if err != nil {
+		helper.InternalServerError(ctx, "Failed to apply tax credits")
 		return
 	}
 
 	// Prepare the response
+	bandLabels := make(map[string]string)
+	for _, bracket := range taxBrackets.TaxBrackets {
+		if _, ok := taxAmountBands.TaxAmountPerBand[bracket.Band]; ok && bracket.Label != "" {
+			bandLabels[bracket.Band] = bracket.Label
+		}
+	}
+
 	response := helper.TaxAmountResponse{
-		TotalTaxAmount:   totalTaxSalary,
 		TaxAmountPerBand: taxAmountBands.TaxAmountPerBand,
-		EffectiveRate:    effectiveRate,
+		BandLabels:       bandLabels,
+		BracketsAsOf:     taxBrackets.AsOf,
+		Stale:            taxBrackets.Stale,
+		BracketSource:    taxBrackets.Source,
+
+		BracketsEffectiveFrom: taxBrackets.EffectiveFrom,
+		BracketVersion:        taxBrackets.Version,
+
+		TaxCredits:        taxCredits.Credits,
+		TotalCreditAmount: taxCredits.TotalCreditAmount,
+	}
+
+	totalCombinedTax := decimal.NewFromFloat(totalTaxSalary)
+	if province != "" {
+		// Apply the refundable federal abatement for the province of residence
+		abatement, err := c.provincialTaxService.CalculateFederalAbatement(province, totalTaxSalary)
+		if err != nil {
+			handleCalculationError(ctx, err, "Failed to calculate federal abatement")
+			return
+		}
+		totalTaxSalary, _ = decimal.NewFromFloat(totalTaxSalary).Sub(decimal.NewFromFloat(abatement)).Round(2).Float64()
+
+		// Calculate the provincial tax on the province's brackets effective on the same date as the federal ones
+		provincialTax, err := c.provincialTaxService.CalculateProvincialTax(provincialBracketCtx, province, taxYear, salary)
+		if err != nil {
+			handleCalculationError(ctx, err, "Failed to calculate provincial tax")
+			return
+		}
+
+		// Calculate the payroll contributions paid in the province
+		contributions, err := c.payrollContributionService.CalculatePayrollContributions(province, taxYear, salary)
+		if err != nil {
+			handleCalculationError(ctx, err, "Failed to calculate payroll contributions")
+			return
+		}
+
+		totalCombinedTax = decimal.NewFromFloat(totalTaxSalary).Add(decimal.NewFromFloat(provincialTax.TotalTaxAmount))
+
+		response.Province = province
+		response.FederalAbatement = abatement
+		response.ProvincialTax = provincialTax
+		response.PayrollContributions = contributions.Contributions
+		response.TotalPayrollContributions = contributions.TotalContributionAmount
+		response.TotalCombinedTaxAmount, _ = totalCombinedTax.Round(2).Float64()
 	}
+	response.TotalTaxAmount = totalTaxSalary
+
+	// Calculate the effective tax rate
+	combinedTaxAmount, _ := totalCombinedTax.Round(2).Float64()
+	effectiveRate, err := c.taxService.CalculateEffectiveRate(combinedTaxAmount, salary)
+	if err != nil {
+		helper.InternalServerError(ctx, "Failed to calculate Effective Rate")
+		return
+	}
+
+	response.EffectiveRate = effectiveRate
 
 	helper.OK(ctx, response)
 }
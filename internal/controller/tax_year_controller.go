@@ -0,0 +1,34 @@
+package controller
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/siparisa/interview-test-server/internal/controller/helper"
+	"github.com/siparisa/interview-test-server/internal/entity"
+	"github.com/siparisa/interview-test-server/internal/service"
+)
+
+type TaxYearController struct {
+	taxYearService service.ITaxYearService
+}
+
+// NewTaxYearController creates a new instance of TaxYearController with the given ITaxYearService.
+func NewTaxYearController(taxYearService service.ITaxYearService) *TaxYearController {
+	return &TaxYearController{
+		taxYearService: taxYearService,
+	}
+}
+
+// GetTaxYears @Summary Get supported tax years
+// @Description List the tax years available from the bracket sources with their source and last refresh time
+// @ID getTaxYears
+// @Produce json
+// @Success 200 {object} TaxYearsResponse
+// @Router /years [get]
+func (c *TaxYearController) GetTaxYears(ctx *gin.Context) {
+	taxYears := c.taxYearService.GetTaxYears()
+	if taxYears == nil {
+		taxYears = []entity.TaxYear{}
+	}
+
+	helper.OK(ctx, helper.TaxYearsResponse{TaxYears: taxYears})
+}
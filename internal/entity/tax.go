@@ -1,16 +1,30 @@
 package entity
 
+import "time"
+
 // TaxBracket represents a tax bracket with minimum and maximum values and a tax rate.
 type TaxBracket struct {
-	Band string  `json:"band"`
-	Max  float64 `json:"max"`
-	Min  float64 `json:"min"`
-	Rate float64 `json:"rate"`
+	Band  string  `json:"band"`
+	Label string  `json:"label,omitempty"`
+	Max   float64 `json:"max"`
+	Min   float64 `json:"min"`
+	Rate  float64 `json:"rate"`
 }
 
 // TaxBrackets represents the response containing an array of TaxBrackets.
 type TaxBrackets struct {
 	TaxBrackets []TaxBracket `json:"tax_brackets"`
+
+	// AsOf is when the brackets were retrieved from their source.
+	AsOf time.Time `json:"-"`
+	// Stale is set when the brackets are served past their cache TTL.
+	Stale bool `json:"-"`
+	// Source is the bracket source the brackets were retrieved from.
+	Source string `json:"-"`
+	// EffectiveFrom is the date this version of the brackets applies from within the tax year.
+	EffectiveFrom time.Time `json:"-"`
+	// Version numbers the distinct brackets recorded for the tax year, starting at 1.
+	Version int `json:"-"`
 }
 
 // TaxCalculationResult represents the final response result
@@ -18,3 +32,242 @@ type TaxCalculationResult struct {
 	TaxAmountPerBand map[string]float64
 	TotalTaxAmount   float64
 }
+
+// TaxCreditInputs represents the amounts claimed towards the non-refundable tax credits.
+type TaxCreditInputs struct {
+	Donations       float64
+	MedicalExpenses float64
+}
+
+// TaxCredit represents a single non-refundable tax credit with the eligible amount it was calculated on.
+type TaxCredit struct {
+	Name           string  `json:"name"`
+	EligibleAmount float64 `json:"eligibleAmount"`
+	Amount         float64 `json:"amount"`
+}
+
+// TaxCredits represents the itemized non-refundable tax credits and their total amount.
+type TaxCredits struct {
+	Credits           []TaxCredit
+	TotalCreditAmount float64
+}
+
+// ProvincialTaxResult represents the provincial tax calculated on the province's own schedule.
+type ProvincialTaxResult struct {
+	Province            string             `json:"province"`
+	TaxAmountPerBand    map[string]float64 `json:"taxAmountPerBand"`
+	BasicPersonalCredit float64            `json:"basicPersonalCredit"`
+	BasicTaxAmount      float64            `json:"basicTaxAmount"`
+	Surtax              float64            `json:"surtax,omitempty"`
+	HealthPremium       float64            `json:"healthPremium,omitempty"`
+	TotalTaxAmount      float64            `json:"totalTaxAmount"`
+
+	BracketSource         string    `json:"bracketSource"`
+	BracketsEffectiveFrom time.Time `json:"bracketsEffectiveFrom"`
+	BracketVersion        int       `json:"bracketVersion,omitempty"`
+}
+
+// PayrollContribution represents a single payroll contribution such as CPP, QPP, EI or QPIP.
+type PayrollContribution struct {
+	Name   string  `json:"name"`
+	Amount float64 `json:"amount"`
+}
+
+// PayrollContributions represents the itemized payroll contributions and their total amount.
+type PayrollContributions struct {
+	Contributions           []PayrollContribution
+	TotalContributionAmount float64
+}
+
+// BenefitInputs represents the family situation used to estimate income-tested benefits.
+type BenefitInputs struct {
+	FamilyNetIncome float64
+	MaritalStatus   string
+	ChildrenUnder6  int
+	Children6To17   int
+}
+
+// BenefitEstimate represents the estimated income-tested benefits for a benefit year.
+type BenefitEstimate struct {
+	BenefitYear        string
+	FamilyNetIncome    float64
+	CanadaChildBenefit float64
+	GSTHSTCredit       float64
+	TotalBenefitAmount float64
+}
+
+// MarginalRateInputs represents the income and family situation used to calculate the marginal effective tax rate.
+type MarginalRateInputs struct {
+	Salary         float64
+	Delta          float64
+	Province       string
+	SpouseSalary   float64
+	MaritalStatus  string
+	ChildrenUnder6 int
+	Children6To17  int
+	OASIncome      float64
+}
+
+// MarginalRateComponent represents the change in one driver of the marginal effective tax rate.
+type MarginalRateComponent struct {
+	Name   string  `json:"name"`
+	Change float64 `json:"change"`
+	Rate   float64 `json:"rate"`
+}
+
+// MarginalRateResult represents the marginal effective tax rate and the components driving it.
+type MarginalRateResult struct {
+	Salary                float64
+	Delta                 float64
+	MarginalEffectiveRate float64
+	Components            []MarginalRateComponent
+	PrimaryDriver         string
+}
+
+// CircuitBreakerStatus represents the state of the circuit breaker around an upstream service.
+type CircuitBreakerStatus struct {
+	State               string     `json:"state"`
+	ConsecutiveFailures int        `json:"consecutiveFailures"`
+	OpenedAt            *time.Time `json:"openedAt,omitempty"`
+	LastStateChange     time.Time  `json:"lastStateChange"`
+	FallbackYears       []string   `json:"fallbackYears,omitempty"`
+}
+
+// CacheStats represents the hit and miss counts of the tax bracket cache.
+type CacheStats struct {
+	Hits      int64 `json:"hits"`
+	Misses    int64 `json:"misses"`
+	Coalesced int64 `json:"coalesced"`
+	Entries   int   `json:"entries"`
+
+	StaleServed     int64 `json:"staleServed"`
+	RefreshFailures int64 `json:"refreshFailures"`
+}
+
+// BracketSourceHealth represents the health of a bracket source of the source chain.
+type BracketSourceHealth struct {
+	Name                string     `json:"name"`
+	Healthy             bool       `json:"healthy"`
+	ConsecutiveFailures int        `json:"consecutiveFailures"`
+	LastError           string     `json:"lastError,omitempty"`
+	LastSuccess         *time.Time `json:"lastSuccess,omitempty"`
+	UnhealthyUntil      *time.Time `json:"unhealthyUntil,omitempty"`
+}
+
+// ShadowStats represents the outcome of cross-checking the brackets served against a shadow bracket source. The
+// mismatched bands are counted by tax year and band, and the mismatched fields by field, or by added and removed for
+// bands only one of the sources has.
+type ShadowStats struct {
+	Source           string           `json:"source"`
+	Comparisons      int64            `json:"comparisons"`
+	Matches          int64            `json:"matches"`
+	Mismatches       int64            `json:"mismatches"`
+	ShadowFailures   int64            `json:"shadowFailures"`
+	MismatchedBands  map[string]int64 `json:"mismatchedBands,omitempty"`
+	MismatchedFields map[string]int64 `json:"mismatchedFields,omitempty"`
+	LastMismatch     *ShadowMismatch  `json:"lastMismatch,omitempty"`
+}
+
+// ShadowMismatch represents the differences found between the brackets served and those of the shadow source.
+type ShadowMismatch struct {
+	TaxYear       string       `json:"taxYear"`
+	PrimarySource string       `json:"primarySource"`
+	ComparedAt    time.Time    `json:"comparedAt"`
+	BandChanges   []BandChange `json:"bandChanges"`
+}
+
+// WarmUpStatus represents the progress of pre-fetching the brackets of the supported tax years at startup. The
+// service is ready once every year was fetched or skipped because no source has it, or the warm-up timed out, with
+// the years that failed last.
+type WarmUpStatus struct {
+	State           string            `json:"state"`
+	Ready           bool              `json:"ready"`
+	StartedAt       time.Time         `json:"startedAt"`
+	CompletedAt     *time.Time        `json:"completedAt,omitempty"`
+	WarmedTaxYears  []string          `json:"warmedTaxYears"`
+	SkippedTaxYears []string          `json:"skippedTaxYears,omitempty"`
+	FailedTaxYears  map[string]string `json:"failedTaxYears,omitempty"`
+}
+
+// TaxYear represents a supported tax year and the bracket source it is served from.
+type TaxYear struct {
+	Year        string    `json:"year"`
+	Source      string    `json:"source"`
+	RefreshedAt time.Time `json:"refreshedAt"`
+}
+
+// TaxBracketVersion represents an immutable recorded version of the tax brackets of a jurisdiction and tax year.
+type TaxBracketVersion struct {
+	Jurisdiction  string       `json:"jurisdiction,omitempty"`
+	TaxYear       string       `json:"taxYear"`
+	Version       int          `json:"version"`
+	EffectiveFrom time.Time    `json:"effectiveFrom"`
+	RecordedAt    time.Time    `json:"recordedAt"`
+	Source        string       `json:"source"`
+	TaxBrackets   []TaxBracket `json:"taxBrackets"`
+}
+
+// BracketOverride represents a bracket table published through the admin API to replace the bracket sources.
+type BracketOverride struct {
+	Jurisdiction  string       `json:"jurisdiction"`
+	TaxYear       string       `json:"taxYear"`
+	Revision      int          `json:"revision"`
+	Reason        string       `json:"reason,omitempty"`
+	PublishedBy   string       `json:"publishedBy"`
+	PublishedAt   time.Time    `json:"publishedAt"`
+	EffectiveFrom time.Time    `json:"effectiveFrom"`
+	TaxBrackets   []TaxBracket `json:"taxBrackets"`
+}
+
+// BracketOverrideStatus represents the staged and active bracket overrides of a jurisdiction and tax year. Previous
+// holds the overrides a rollback returns to, the most recent first.
+type BracketOverrideStatus struct {
+	Jurisdiction string            `json:"jurisdiction"`
+	TaxYear      string            `json:"taxYear"`
+	Staged       *BracketOverride  `json:"staged,omitempty"`
+	Active       *BracketOverride  `json:"active,omitempty"`
+	Previous     []BracketOverride `json:"previous,omitempty"`
+}
+
+// BracketImpactReport represents the changes between two bracket tables of a jurisdiction and tax year: the bands
+// that changed and the tax of reference salaries under both tables.
+type BracketImpactReport struct {
+	Jurisdiction string         `json:"jurisdiction,omitempty"`
+	TaxYear      string         `json:"taxYear"`
+	Before       string         `json:"before"`
+	After        string         `json:"after"`
+	GeneratedAt  time.Time      `json:"generatedAt"`
+	BandChanges  []BandChange   `json:"bandChanges"`
+	Salaries     []SalaryImpact `json:"salaries"`
+}
+
+// BandChange represents a band added, removed or changed between two bracket tables, with the changed fields.
+type BandChange struct {
+	Band   string      `json:"band"`
+	Change string      `json:"change"`
+	Fields []string    `json:"fields,omitempty"`
+	Before *TaxBracket `json:"before,omitempty"`
+	After  *TaxBracket `json:"after,omitempty"`
+}
+
+// SalaryImpact represents the tax of a reference salary under two bracket tables.
+type SalaryImpact struct {
+	Salary          float64 `json:"salary"`
+	BeforeTaxAmount float64 `json:"beforeTaxAmount"`
+	AfterTaxAmount  float64 `json:"afterTaxAmount"`
+	Difference      float64 `json:"difference"`
+}
+
+// BracketAuditEntry represents a change made to the bracket overrides through the admin API.
+type BracketAuditEntry struct {
+	Time         time.Time `json:"time"`
+	Actor        string    `json:"actor"`
+	Action       string    `json:"action"`
+	Jurisdiction string    `json:"jurisdiction"`
+	TaxYear      string    `json:"taxYear"`
+	Revision     int       `json:"revision,omitempty"`
+	Reason       string    `json:"reason,omitempty"`
+	// EffectiveFrom is the date a published table takes effect from
+	EffectiveFrom *time.Time   `json:"effectiveFrom,omitempty"`
+	TaxBrackets   []TaxBracket `json:"taxBrackets,omitempty"`
+}
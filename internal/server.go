@@ -3,13 +3,27 @@ package internal
 import (
 	"github.com/gin-gonic/gin"
 	"github.com/siparisa/interview-test-server/internal/controller"
+	"github.com/siparisa/interview-test-server/internal/controller/helper"
 	"log"
 )
 
 // SetupRouter creates and configures the Gin router for the application with the provided logger
-func SetupRouter(logger *log.Logger, taxController *controller.TaxController) (*gin.Engine, error) {
+func SetupRouter(
+	logger *log.Logger,
+	taxController *controller.TaxController,
+	benefitController *controller.BenefitController,
+	marginalRateController *controller.MarginalRateController,
+	taxYearController *controller.TaxYearController,
+	bracketController *controller.BracketController,
+	statusController *controller.StatusController,
+	adminController *controller.AdminController,
+	adminTokens map[string]string,
+) (*gin.Engine, error) {
 	router := gin.Default()
 
+	router.GET("/status", statusController.GetStatus)
+	router.GET("/ready", statusController.GetReadiness)
+
 	// Create a router group for "income-tax" endpoints
 	incomeTaxGroup := router.Group("/income-tax")
 
@@ -18,5 +32,63 @@ func SetupRouter(logger *log.Logger, taxController *controller.TaxController) (*
 		taxController.GetTotalIncomeTax(c)
 	})
 
+	incomeTaxGroup.GET("/benefits", func(c *gin.Context) {
+		logger.Println("Handling GET request for /income-tax/benefits")
+		benefitController.GetBenefits(c)
+	})
+
+	incomeTaxGroup.GET("/marginal-rate", func(c *gin.Context) {
+		logger.Println("Handling GET request for /income-tax/marginal-rate")
+		marginalRateController.GetMarginalEffectiveRate(c)
+	})
+
+	incomeTaxGroup.GET("/years", func(c *gin.Context) {
+		logger.Println("Handling GET request for /income-tax/years")
+		taxYearController.GetTaxYears(c)
+	})
+
+	incomeTaxGroup.GET("/brackets/*table", func(c *gin.Context) {
+		logger.Println("Handling GET request for /income-tax/brackets")
+		bracketController.GetTaxBrackets(c)
+	})
+
+	// Create a router group for the "admin" endpoints, only open to the admins holding a token
+	adminGroup := router.Group("/admin", helper.AdminAuth(adminTokens))
+
+	adminGroup.GET("/brackets/:jurisdiction/:year", func(c *gin.Context) {
+		logger.Println("Handling GET request for /admin/brackets")
+		adminController.GetBracketOverride(c)
+	})
+
+	adminGroup.PUT("/brackets/:jurisdiction/:year", func(c *gin.Context) {
+		logger.Println("Handling PUT request for /admin/brackets")
+		adminController.PublishBracketOverride(c)
+	})
+
+	adminGroup.POST("/brackets/:jurisdiction/:year/preview", func(c *gin.Context) {
+		logger.Println("Handling POST request for /admin/brackets/preview")
+		adminController.PreviewBracketOverride(c)
+	})
+
+	adminGroup.POST("/brackets/:jurisdiction/:year/activate", func(c *gin.Context) {
+		logger.Println("Handling POST request for /admin/brackets/activate")
+		adminController.ActivateBracketOverride(c)
+	})
+
+	adminGroup.POST("/brackets/:jurisdiction/:year/rollback", func(c *gin.Context) {
+		logger.Println("Handling POST request for /admin/brackets/rollback")
+		adminController.RollbackBracketOverride(c)
+	})
+
+	adminGroup.GET("/brackets/:jurisdiction/:year/impact", func(c *gin.Context) {
+		logger.Println("Handling GET request for /admin/brackets/impact")
+		adminController.GetBracketImpact(c)
+	})
+
+	adminGroup.GET("/audit", func(c *gin.Context) {
+		logger.Println("Handling GET request for /admin/audit")
+		adminController.GetBracketAuditLog(c)
+	})
+
 	return router, nil
 }
@@ -0,0 +1,264 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"github.com/shopspring/decimal"
+	"github.com/siparisa/interview-test-server/internal/entity"
+	"sort"
+	"strconv"
+)
+
+const (
+	MaritalStatusSingle    = "single"
+	MaritalStatusMarried   = "married"
+	MaritalStatusCommonLaw = "common-law"
+)
+
+// IBenefitService defines the interface for income-tested benefit estimations.
+type IBenefitService interface {
+	CalculateFamilyNetIncome(salaries ...float64) (float64, error)
+	EstimateBenefits(taxYear string, inputs entity.BenefitInputs) (*entity.BenefitEstimate, error)
+	GetBenefitTaxYears() []string
+	CalculateOASRecovery(taxYear string, netIncome float64, oasIncome float64) (float64, error)
+}
+
+// childBenefitRules holds the Canada Child Benefit amounts and phase-out thresholds for a benefit year.
+type childBenefitRules struct {
+	maxUnder6          float64
+	max6To17           float64
+	firstThreshold     float64
+	secondThreshold    float64
+	firstPhaseOutRate  []float64 // indexed by number of children, four or more use the last rate
+	secondPhaseOutRate []float64
+}
+
+// gstCreditRules holds the GST/HST credit amounts and phase-out threshold for a benefit year.
+type gstCreditRules struct {
+	adultAmount       float64
+	childAmount       float64
+	singleSupplement  float64
+	supplementBase    float64 // single supplement is phased in on net income above this amount
+	supplementRate    float64
+	phaseOutThreshold float64
+	phaseOutRate      float64
+}
+
+var childBenefitPhaseOutRates = [][]float64{
+	{0.07, 0.135, 0.19, 0.23},
+	{0.032, 0.057, 0.08, 0.095},
+}
+
+// childBenefitRulesByYear holds the Canada Child Benefit tables keyed by the tax year the benefit year is based on.
+var childBenefitRulesByYear = map[string]childBenefitRules{
+	"2019": newChildBenefitRules(6765, 5708, 31711, 68708),
+	"2020": newChildBenefitRules(6833, 5765, 32028, 69395),
+	"2021": newChildBenefitRules(6997, 5903, 32797, 71060),
+	"2022": newChildBenefitRules(7437, 6275, 34863, 75537),
+}
+
+// gstCreditRulesByYear holds the GST/HST credit tables keyed by the tax year the benefit year is based on, e.g. the
+// 2019 row is the July 2020 - June 2021 benefit year.
+var gstCreditRulesByYear = map[string]gstCreditRules{
+	"2019": newGSTCreditRules(296, 155, 9590, 38507),
+	"2020": newGSTCreditRules(299, 157, 9686, 38892),
+	"2021": newGSTCreditRules(306, 161, 9919, 39826),
+	"2022": newGSTCreditRules(325, 171, 10544, 42335),
+}
+
+// oasRecoveryThresholdsByYear holds the net income above which Old Age Security is recovered.
+var oasRecoveryThresholdsByYear = map[string]float64{
+	"2019": 77580,
+	"2020": 79054,
+	"2021": 79845,
+	"2022": 81761,
+}
+
+// oasRecoveryRate is the share of net income above the threshold recovered from the Old Age Security received.
+const oasRecoveryRate = 0.15
+
+func newChildBenefitRules(maxUnder6, max6To17, firstThreshold, secondThreshold float64) childBenefitRules {
+	return childBenefitRules{
+		maxUnder6:          maxUnder6,
+		max6To17:           max6To17,
+		firstThreshold:     firstThreshold,
+		secondThreshold:    secondThreshold,
+		firstPhaseOutRate:  childBenefitPhaseOutRates[0],
+		secondPhaseOutRate: childBenefitPhaseOutRates[1],
+	}
+}
+
+func newGSTCreditRules(adultAmount, childAmount, supplementBase, phaseOutThreshold float64) gstCreditRules {
+	return gstCreditRules{
+		adultAmount:       adultAmount,
+		childAmount:       childAmount,
+		singleSupplement:  childAmount,
+		supplementBase:    supplementBase,
+		supplementRate:    0.02,
+		phaseOutThreshold: phaseOutThreshold,
+		phaseOutRate:      0.05,
+	}
+}
+
+type benefitService struct{}
+
+// NewBenefitService creates a new instance of the benefitService.
+func NewBenefitService() IBenefitService {
+	return &benefitService{}
+}
+
+// CalculateFamilyNetIncome calculates the family net income from the salaries of the family members.
+func (s *benefitService) CalculateFamilyNetIncome(salaries ...float64) (float64, error) {
+	familyNetIncome := decimal.NewFromFloat(0)
+	for _, salary := range salaries {
+		if salary < 0 {
+			return 0, errors.New("salary cannot be negative")
+		}
+		familyNetIncome = familyNetIncome.Add(decimal.NewFromFloat(salary))
+	}
+
+	roundedAmount, _ := familyNetIncome.Round(2).Float64()
+	return roundedAmount, nil
+}
+
+// EstimateBenefits estimates the Canada Child Benefit and GST/HST credit paid in the benefit year that starts in July
+// of the year following the given tax year.
+func (s *benefitService) EstimateBenefits(taxYear string, inputs entity.BenefitInputs) (*entity.BenefitEstimate, error) {
+	if inputs.ChildrenUnder6 < 0 || inputs.Children6To17 < 0 {
+		return nil, errors.New("number of children cannot be negative")
+	}
+
+	switch inputs.MaritalStatus {
+	case MaritalStatusSingle, MaritalStatusMarried, MaritalStatusCommonLaw:
+	default:
+		return nil, fmt.Errorf("invalid marital status %q", inputs.MaritalStatus)
+	}
+
+	childBenefit, okChild := childBenefitRulesByYear[taxYear]
+	gstCredit, okGST := gstCreditRulesByYear[taxYear]
+	year, err := strconv.Atoi(taxYear)
+	if !okChild || !okGST || err != nil {
+		return nil, fmt.Errorf("benefit tables for %s: %w", taxYear, ErrTaxYearRulesNotFound)
+	}
+
+	canadaChildBenefit := calculateCanadaChildBenefit(childBenefit, inputs)
+	gstHSTCredit := calculateGSTHSTCredit(gstCredit, inputs)
+	totalBenefitAmount, _ := decimal.NewFromFloat(canadaChildBenefit).Add(decimal.NewFromFloat(gstHSTCredit)).Round(2).Float64()
+
+	estimate := &entity.BenefitEstimate{
+		BenefitYear:        fmt.Sprintf("July %d - June %d", year+1, year+2),
+		FamilyNetIncome:    inputs.FamilyNetIncome,
+		CanadaChildBenefit: canadaChildBenefit,
+		GSTHSTCredit:       gstHSTCredit,
+		TotalBenefitAmount: totalBenefitAmount,
+	}
+
+	return estimate, nil
+}
+
+// GetBenefitTaxYears returns the tax years both the Canada Child Benefit and the GST/HST credit tables are published
+// for, in ascending order.
+func (s *benefitService) GetBenefitTaxYears() []string {
+	var taxYears []string
+	for taxYear := range childBenefitRulesByYear {
+		if _, ok := gstCreditRulesByYear[taxYear]; ok {
+			taxYears = append(taxYears, taxYear)
+		}
+	}
+	sort.Strings(taxYears)
+
+	return taxYears
+}
+
+// CalculateOASRecovery calculates the Old Age Security recovery tax on the net income above the threshold for the
+// given tax year, up to the Old Age Security received. The threshold of the year is only needed when Old Age Security
+// is received.
+func (s *benefitService) CalculateOASRecovery(taxYear string, netIncome float64, oasIncome float64) (float64, error) {
+	if oasIncome <= 0 {
+		return 0, nil
+	}
+
+	threshold, ok := oasRecoveryThresholdsByYear[taxYear]
+	if !ok {
+		return 0, fmt.Errorf("OAS recovery threshold for %s: %w", taxYear, ErrTaxYearRulesNotFound)
+	}
+
+	recovery := decimal.NewFromFloat(netIncome).Sub(decimal.NewFromFloat(threshold)).Mul(decimal.NewFromFloat(oasRecoveryRate))
+	return roundPositive(decimal.Min(recovery, decimal.NewFromFloat(oasIncome))), nil
+}
+
+// calculateCanadaChildBenefit calculates the maximum benefit for the children, reduced at the phase-out rates for the
+// number of children on family net income above each threshold.
+func calculateCanadaChildBenefit(rules childBenefitRules, inputs entity.BenefitInputs) float64 {
+	children := inputs.ChildrenUnder6 + inputs.Children6To17
+	if children == 0 {
+		return 0
+	}
+
+	maxBenefit := decimal.NewFromFloat(rules.maxUnder6).Mul(decimal.NewFromInt(int64(inputs.ChildrenUnder6))).
+		Add(decimal.NewFromFloat(rules.max6To17).Mul(decimal.NewFromInt(int64(inputs.Children6To17))))
+
+	rateIndex := children - 1
+	if rateIndex >= len(rules.firstPhaseOutRate) {
+		rateIndex = len(rules.firstPhaseOutRate) - 1
+	}
+
+	income := decimal.NewFromFloat(inputs.FamilyNetIncome)
+	firstThreshold := decimal.NewFromFloat(rules.firstThreshold)
+	secondThreshold := decimal.NewFromFloat(rules.secondThreshold)
+
+	reduction := decimal.NewFromFloat(0)
+	if income.GreaterThan(firstThreshold) {
+		reduction = decimal.Min(income, secondThreshold).Sub(firstThreshold).
+			Mul(decimal.NewFromFloat(rules.firstPhaseOutRate[rateIndex]))
+	}
+	if income.GreaterThan(secondThreshold) {
+		reduction = reduction.Add(income.Sub(secondThreshold).Mul(decimal.NewFromFloat(rules.secondPhaseOutRate[rateIndex])))
+	}
+
+	return roundPositive(maxBenefit.Sub(reduction))
+}
+
+// calculateGSTHSTCredit calculates the credit for the adults and children of the family, reduced on family net income
+// above the phase-out threshold. Singles receive the supplement phased in on their income, single parents in full, and
+// claim their first child at the adult amount.
+func calculateGSTHSTCredit(rules gstCreditRules, inputs entity.BenefitInputs) float64 {
+	children := inputs.ChildrenUnder6 + inputs.Children6To17
+	income := decimal.NewFromFloat(inputs.FamilyNetIncome)
+
+	credit := decimal.NewFromFloat(rules.adultAmount)
+	if inputs.MaritalStatus == MaritalStatusSingle {
+		supplement := decimal.NewFromFloat(rules.singleSupplement)
+		if children > 0 {
+			credit = credit.Add(decimal.NewFromFloat(rules.adultAmount))
+			children--
+		} else {
+			supplement = decimal.Min(supplement, income.Sub(decimal.NewFromFloat(rules.supplementBase)).
+				Mul(decimal.NewFromFloat(rules.supplementRate)))
+		}
+
+		if supplement.GreaterThan(decimal.NewFromFloat(0)) {
+			credit = credit.Add(supplement)
+		}
+	} else {
+		credit = credit.Add(decimal.NewFromFloat(rules.adultAmount))
+	}
+	credit = credit.Add(decimal.NewFromFloat(rules.childAmount).Mul(decimal.NewFromInt(int64(children))))
+
+	threshold := decimal.NewFromFloat(rules.phaseOutThreshold)
+	if income.GreaterThan(threshold) {
+		credit = credit.Sub(income.Sub(threshold).Mul(decimal.NewFromFloat(rules.phaseOutRate)))
+	}
+
+	return roundPositive(credit)
+}
+
+// roundPositive rounds the amount to 2 decimal places, floored at zero.
+func roundPositive(amount decimal.Decimal) float64 {
+	if amount.LessThan(decimal.NewFromFloat(0)) {
+		return 0
+	}
+
+	roundedAmount, _ := amount.Round(2).Float64()
+	return roundedAmount
+}
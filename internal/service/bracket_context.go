@@ -0,0 +1,49 @@
+package service
+
+import (
+	"context"
+	"time"
+)
+
+type asOfKey struct{}
+
+type bracketVersionKey struct{}
+
+// WithAsOf returns a context asking the bracket sources for the version of the brackets effective on the given date.
+func WithAsOf(ctx context.Context, asOf time.Time) context.Context {
+	return context.WithValue(ctx, asOfKey{}, asOf)
+}
+
+// WithBracketVersion returns a context asking for a recorded version of the brackets, see IBracketHistoryService.
+func WithBracketVersion(ctx context.Context, version int) context.Context {
+	return context.WithValue(ctx, bracketVersionKey{}, version)
+}
+
+// asOfFromContext returns the date the brackets should be effective on, today unless the context asks otherwise.
+func asOfFromContext(ctx context.Context) time.Time {
+	if asOf, ok := requestedAsOf(ctx); ok {
+		return asOf
+	}
+	return time.Now()
+}
+
+// requestedAsOf returns the date the context asks the brackets to be effective on, if any.
+func requestedAsOf(ctx context.Context) (time.Time, bool) {
+	asOf, ok := ctx.Value(asOfKey{}).(time.Time)
+	return asOf, ok
+}
+
+// bracketVersionFromContext returns the recorded version the context asks for, if any.
+func bracketVersionFromContext(ctx context.Context) (int, bool) {
+	version, ok := ctx.Value(bracketVersionKey{}).(int)
+	return version, ok
+}
+
+// startOfTaxYear returns January 1 of the tax year, the date brackets apply from unless amended mid-year.
+func startOfTaxYear(taxYear string) time.Time {
+	start, err := time.Parse("2006", taxYear)
+	if err != nil {
+		return time.Time{}
+	}
+	return start
+}
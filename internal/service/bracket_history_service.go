@@ -0,0 +1,327 @@
+package service
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/goccy/go-json"
+	"github.com/siparisa/interview-test-server/internal/entity"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrTaxBracketVersionNotFound is returned when a recorded version of the brackets is asked for that does not exist.
+var ErrTaxBracketVersionNotFound = errors.New("tax bracket version not found")
+
+// BracketHistoryOptions configures where the bracket history is kept.
+type BracketHistoryOptions struct {
+	// File is an append-only file of JSON lines the versions are written to, so the history survives restarts. When
+	// empty, the history is kept in memory only.
+	File string
+}
+
+// IBracketHistoryService is an IJurisdictionTaxBracketService that records every distinct version of the federal and
+// provincial brackets it serves, so a calculation can be reproduced with the exact brackets it used. An impact report
+// is generated for every version that replaces an earlier one.
+type IBracketHistoryService interface {
+	IJurisdictionTaxBracketService
+	GetTaxBracketHistory(jurisdiction string, taxYear string) []entity.TaxBracketVersion
+	GetBracketImpactReports(jurisdiction string, taxYear string) []entity.BracketImpactReport
+	CompareTaxBracketVersions(jurisdiction string, taxYear string, before int, after int, salaries []float64) (*entity.BracketImpactReport, error)
+}
+
+type bracketHistoryService struct {
+	next          IJurisdictionTaxBracketService
+	reportService IBracketReportService
+	options       BracketHistoryOptions
+	logger        *log.Logger
+
+	mu       sync.Mutex
+	versions map[string][]entity.TaxBracketVersion // by jurisdiction and year, see historyKey
+}
+
+// NewBracketHistoryService wraps the given IJurisdictionTaxBracketService with an immutable bracket history, loading
+// the versions recorded before from the history file. The impact of new versions is reported to the
+// IBracketReportService.
+func NewBracketHistoryService(next IJurisdictionTaxBracketService, reportService IBracketReportService, options BracketHistoryOptions, logger *log.Logger) (IBracketHistoryService, error) {
+	s := &bracketHistoryService{
+		next:          next,
+		reportService: reportService,
+		options:       options,
+		logger:        logger,
+		versions:      make(map[string][]entity.TaxBracketVersion),
+	}
+
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// GetTaxBracket retrieves the federal tax brackets for the given year, see GetJurisdictionTaxBracket.
+func (s *bracketHistoryService) GetTaxBracket(ctx context.Context, taxYear string) (*entity.TaxBrackets, error) {
+	return s.GetJurisdictionTaxBracket(ctx, FederalJurisdiction, taxYear)
+}
+
+// GetJurisdictionTaxBracket retrieves the tax brackets of the jurisdiction and year from the wrapped service and
+// numbers them with their recorded version. When the context asks for a version with WithBracketVersion, that version
+// is returned from the history instead. When it asks for a date with WithAsOf, the brackets are answered from the
+// versions recorded by that date, and only retrieved from the wrapped service when none was in effect on it.
+func (s *bracketHistoryService) GetJurisdictionTaxBracket(ctx context.Context, jurisdiction string, taxYear string) (*entity.TaxBrackets, error) {
+	jurisdiction = normalizeJurisdiction(jurisdiction)
+
+	if version, ok := bracketVersionFromContext(ctx); ok {
+		return s.getVersion(jurisdiction, taxYear, version)
+	}
+
+	if asOf, ok := requestedAsOf(ctx); ok {
+		if taxBrackets, ok := s.getVersionAsOf(jurisdiction, taxYear, asOf); ok {
+			return taxBrackets, nil
+		}
+	}
+
+	taxBrackets, err := s.next.GetJurisdictionTaxBracket(ctx, jurisdiction, taxYear)
+	if err != nil {
+		return nil, err
+	}
+
+	taxBrackets.Version = s.record(jurisdiction, taxYear, taxBrackets)
+	return taxBrackets, nil
+}
+
+// GetTaxBracketHistory lists the recorded versions of the brackets of the jurisdiction and tax year, oldest first.
+func (s *bracketHistoryService) GetTaxBracketHistory(jurisdiction string, taxYear string) []entity.TaxBracketVersion {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := historyKey(normalizeJurisdiction(jurisdiction), taxYear)
+	history := make([]entity.TaxBracketVersion, 0, len(s.versions[key]))
+	for _, version := range s.versions[key] {
+		version.TaxBrackets = append([]entity.TaxBracket(nil), version.TaxBrackets...)
+		history = append(history, version)
+	}
+
+	return history
+}
+
+// GetBracketImpactReports lists the impact reports of the brackets of the jurisdiction and tax year, oldest first.
+func (s *bracketHistoryService) GetBracketImpactReports(jurisdiction string, taxYear string) []entity.BracketImpactReport {
+	return s.reportService.GetBracketImpactReports(jurisdiction, taxYear)
+}
+
+// CompareTaxBracketVersions reports the impact of going from one recorded version of the brackets of the jurisdiction
+// and tax year to another on the given salaries, or on the reference salaries when none are given.
+func (s *bracketHistoryService) CompareTaxBracketVersions(jurisdiction string, taxYear string, before int, after int, salaries []float64) (*entity.BracketImpactReport, error) {
+	jurisdiction = normalizeJurisdiction(jurisdiction)
+	beforeBrackets, err := s.getVersion(jurisdiction, taxYear, before)
+	if err != nil {
+		return nil, err
+	}
+	afterBrackets, err := s.getVersion(jurisdiction, taxYear, after)
+	if err != nil {
+		return nil, err
+	}
+
+	report, err := s.reportService.CompareTaxBrackets(taxYear, beforeBrackets, afterBrackets, salaries)
+	if err != nil {
+		return nil, err
+	}
+	report.Jurisdiction = jurisdiction
+	report.Before = describeVersion(beforeBrackets.Version, beforeBrackets.Source)
+	report.After = describeVersion(afterBrackets.Version, afterBrackets.Source)
+
+	return report, nil
+}
+
+// getVersion returns a recorded version of the brackets of the jurisdiction and tax year.
+func (s *bracketHistoryService) getVersion(jurisdiction string, taxYear string, version int) (*entity.TaxBrackets, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	versions := s.versions[historyKey(jurisdiction, taxYear)]
+	if version < 1 || version > len(versions) {
+		return nil, ErrTaxBracketVersionNotFound
+	}
+
+	return versionTaxBrackets(versions[version-1]), nil
+}
+
+// getVersionAsOf returns the recorded version of the brackets of the jurisdiction and tax year that was in effect on
+// the given date as far as was known then: of the versions recorded by that date, the one with the latest effective
+// date on or before it, the latest recorded one when several have that date.
+func (s *bracketHistoryService) getVersionAsOf(jurisdiction string, taxYear string, asOf time.Time) (*entity.TaxBrackets, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	versions := s.versions[historyKey(jurisdiction, taxYear)]
+	var effective *entity.TaxBracketVersion
+	for i := range versions {
+		if versions[i].EffectiveFrom.After(asOf) || versions[i].RecordedAt.After(asOf) {
+			continue
+		}
+		if effective == nil || !versions[i].EffectiveFrom.Before(effective.EffectiveFrom) {
+			effective = &versions[i]
+		}
+	}
+	if effective == nil {
+		return nil, false
+	}
+
+	return versionTaxBrackets(*effective), true
+}
+
+// record returns the version of the brackets, recording them as a new version when they differ from the latest version
+// with the same effective date. Recorded versions are never changed.
+func (s *bracketHistoryService) record(jurisdiction string, taxYear string, taxBrackets *entity.TaxBrackets) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// The new version replaces the latest version with the same effective date, or amends the version in effect before
+	// it. A version effective before every recorded one, e.g. a table backfilled for an earlier date, replaces none.
+	key := historyKey(jurisdiction, taxYear)
+	versions := s.versions[key]
+	var replaced, amended *entity.TaxBracketVersion
+	for i := len(versions) - 1; i >= 0; i-- {
+		if versions[i].EffectiveFrom.Equal(taxBrackets.EffectiveFrom) {
+			if sameTaxBrackets(versions[i].TaxBrackets, taxBrackets.TaxBrackets) {
+				return versions[i].Version
+			}
+			if replaced == nil {
+				replaced = &versions[i]
+			}
+		}
+		if versions[i].EffectiveFrom.Before(taxBrackets.EffectiveFrom) && (amended == nil || versions[i].EffectiveFrom.After(amended.EffectiveFrom)) {
+			amended = &versions[i]
+		}
+	}
+	if replaced == nil {
+		replaced = amended
+	}
+
+	version := entity.TaxBracketVersion{
+		Jurisdiction:  jurisdiction,
+		TaxYear:       taxYear,
+		Version:       len(versions) + 1,
+		EffectiveFrom: taxBrackets.EffectiveFrom,
+		RecordedAt:    time.Now(),
+		Source:        taxBrackets.Source,
+		TaxBrackets:   append([]entity.TaxBracket(nil), taxBrackets.TaxBrackets...),
+	}
+	s.versions[key] = append(versions, version)
+
+	if err := s.persist(version); err != nil {
+		s.logger.Printf("failed to write version %d of the %s tax brackets to the history: %v", version.Version, key, err)
+	}
+
+	if replaced != nil {
+		s.reportService.ReportTaxBracketChange(jurisdiction, taxYear, versionTaxBrackets(*replaced), versionTaxBrackets(version))
+	}
+
+	return version.Version
+}
+
+// load reads the versions recorded in the history file, if any.
+func (s *bracketHistoryService) load() error {
+	if s.options.File == "" {
+		return nil
+	}
+
+	file, err := os.Open(s.options.File)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		var version entity.TaxBracketVersion
+		if err := json.Unmarshal(scanner.Bytes(), &version); err != nil {
+			return fmt.Errorf("invalid tax bracket history line %d: %w", line, err)
+		}
+		// Versions recorded before the provincial brackets were versioned have no jurisdiction
+		version.Jurisdiction = normalizeJurisdiction(version.Jurisdiction)
+		key := historyKey(version.Jurisdiction, version.TaxYear)
+		if version.Version != len(s.versions[key])+1 {
+			return fmt.Errorf("invalid tax bracket history line %d: version %d of %s out of order", line, version.Version, key)
+		}
+
+		s.versions[key] = append(s.versions[key], version)
+	}
+
+	return scanner.Err()
+}
+
+// persist appends a version to the history file, if any.
+func (s *bracketHistoryService) persist(version entity.TaxBracketVersion) error {
+	if s.options.File == "" {
+		return nil
+	}
+
+	line, err := json.Marshal(version)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(s.options.File, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	_, err = file.Write(append(line, '\n'))
+	return err
+}
+
+// versionTaxBrackets returns the brackets of a recorded version.
+func versionTaxBrackets(version entity.TaxBracketVersion) *entity.TaxBrackets {
+	return &entity.TaxBrackets{
+		TaxBrackets:   append([]entity.TaxBracket(nil), version.TaxBrackets...),
+		AsOf:          version.RecordedAt,
+		Source:        version.Source,
+		EffectiveFrom: version.EffectiveFrom,
+		Version:       version.Version,
+	}
+}
+
+// normalizeJurisdiction returns the federal jurisdiction for an empty or federal jurisdiction, and the upper case
+// province code otherwise.
+func normalizeJurisdiction(jurisdiction string) string {
+	if jurisdiction == "" || strings.EqualFold(jurisdiction, FederalJurisdiction) {
+		return FederalJurisdiction
+	}
+	return strings.ToUpper(jurisdiction)
+}
+
+// historyKey is the key of the versions of the brackets of a normalized jurisdiction and year.
+func historyKey(jurisdiction string, taxYear string) string {
+	return jurisdiction + "/" + taxYear
+}
+
+// sameTaxBrackets reports whether two bracket tables are identical.
+func sameTaxBrackets(a []entity.TaxBracket, b []entity.TaxBracket) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// describeVersion describes a recorded version of the brackets in an impact report.
+func describeVersion(version int, source string) string {
+	return fmt.Sprintf("version %d from %s", version, source)
+}
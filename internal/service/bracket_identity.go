@@ -0,0 +1,67 @@
+package service
+
+import (
+	"fmt"
+	"github.com/shopspring/decimal"
+	"github.com/siparisa/interview-test-server/internal/entity"
+	"strings"
+)
+
+// identifyTaxBrackets fills in the band and label of the brackets their source left empty. Source-provided names are
+// kept as is. Otherwise the band is derived from the bounds, e.g. "0-47630" and "210371+" for the open-ended top
+// band, so it stays the same when other bands are added or reordered. The brackets must be validated first.
+func identifyTaxBrackets(taxBrackets *entity.TaxBrackets) {
+	last := len(taxBrackets.TaxBrackets) - 1
+	for i := range taxBrackets.TaxBrackets {
+		bracket := &taxBrackets.TaxBrackets[i]
+		min := formatBound(bracket.Min)
+		max := formatBound(bracket.Max)
+		rate := decimal.NewFromFloat(bracket.Rate).Mul(decimal.NewFromFloat(100)).String() + "%"
+
+		if bracket.Band == "" {
+			if i == last {
+				bracket.Band = min + "+"
+			} else {
+				bracket.Band = min + "-" + max
+			}
+		}
+
+		if bracket.Label == "" {
+			switch {
+			case i == last && bracket.Min == 0:
+				bracket.Label = fmt.Sprintf("%s on all income", rate)
+			case i == last:
+				bracket.Label = fmt.Sprintf("%s over $%s", rate, formatAmount(bracket.Min))
+			case bracket.Min == 0:
+				bracket.Label = fmt.Sprintf("%s on first $%s", rate, formatAmount(bracket.Max))
+			default:
+				bracket.Label = fmt.Sprintf("%s on $%s to $%s", rate, formatAmount(bracket.Min), formatAmount(bracket.Max))
+			}
+		}
+	}
+}
+
+// formatBound formats a band bound without trailing zeros, e.g. 47630 or 47630.5.
+func formatBound(amount float64) string {
+	return decimal.NewFromFloat(amount).String()
+}
+
+// formatAmount formats a band bound with thousands separators, e.g. 47,630 or 47,630.50.
+func formatAmount(amount float64) string {
+	d := decimal.NewFromFloat(amount)
+	whole := d.Truncate(0).String()
+
+	var b strings.Builder
+	for i, digit := range whole {
+		if i > 0 && (len(whole)-i)%3 == 0 {
+			b.WriteByte(',')
+		}
+		b.WriteRune(digit)
+	}
+
+	if !d.Equal(d.Truncate(0)) {
+		b.WriteString(strings.TrimPrefix(d.Sub(d.Truncate(0)).StringFixed(2), "0"))
+	}
+
+	return b.String()
+}
@@ -0,0 +1,163 @@
+package service
+
+import (
+	"github.com/shopspring/decimal"
+	"github.com/siparisa/interview-test-server/internal/entity"
+	"sort"
+	"time"
+)
+
+// The changes of a band between two bracket tables.
+const (
+	BandAdded   = "added"
+	BandRemoved = "removed"
+	BandChanged = "changed"
+)
+
+// DefaultReferenceSalaries are the salaries the impact of a bracket table change is calculated on when none are
+// configured.
+var DefaultReferenceSalaries = []float64{25000, 50000, 75000, 100000, 150000, 250000, 500000}
+
+// BracketImpactOptions configures the reference salaries of the impact reports.
+type BracketImpactOptions struct {
+	ReferenceSalaries []float64
+}
+
+// IBracketImpactService defines the interface for reporting the impact of replacing a bracket table.
+type IBracketImpactService interface {
+	CompareTaxBrackets(taxYear string, before *entity.TaxBrackets, after *entity.TaxBrackets, salaries []float64) (*entity.BracketImpactReport, error)
+}
+
+type bracketImpactService struct {
+	taxService ITaxService
+	options    BracketImpactOptions
+}
+
+// NewBracketImpactService creates a new instance of the bracketImpactService, calculating the tax of the reference
+// salaries with the given ITaxService.
+func NewBracketImpactService(taxService ITaxService, options BracketImpactOptions) IBracketImpactService {
+	if len(options.ReferenceSalaries) == 0 {
+		options.ReferenceSalaries = DefaultReferenceSalaries
+	}
+
+	return &bracketImpactService{
+		taxService: taxService,
+		options:    options,
+	}
+}
+
+// CompareTaxBrackets lists the bands that changed between the two bracket tables and runs each salary through
+// CalculateTaxPerBand on both, using the reference salaries when no salaries are given. The Before and After of the
+// report are left for the caller to describe the tables.
+func (s *bracketImpactService) CompareTaxBrackets(taxYear string, before *entity.TaxBrackets, after *entity.TaxBrackets, salaries []float64) (*entity.BracketImpactReport, error) {
+	if len(salaries) == 0 {
+		salaries = s.options.ReferenceSalaries
+	}
+
+	report := &entity.BracketImpactReport{
+		TaxYear:     taxYear,
+		GeneratedAt: time.Now(),
+		BandChanges: compareBands(before.TaxBrackets, after.TaxBrackets),
+	}
+
+	for _, salary := range salaries {
+		beforeTax, err := s.taxService.CalculateTaxPerBand(before, salary)
+		if err != nil {
+			return nil, err
+		}
+		afterTax, err := s.taxService.CalculateTaxPerBand(after, salary)
+		if err != nil {
+			return nil, err
+		}
+
+		difference, _ := decimal.NewFromFloat(afterTax.TotalTaxAmount).Sub(decimal.NewFromFloat(beforeTax.TotalTaxAmount)).Round(2).Float64()
+		report.Salaries = append(report.Salaries, entity.SalaryImpact{
+			Salary:          salary,
+			BeforeTaxAmount: beforeTax.TotalTaxAmount,
+			AfterTaxAmount:  afterTax.TotalTaxAmount,
+			Difference:      difference,
+		})
+	}
+
+	return report, nil
+}
+
+// compareBands lists the bands that changed between two bracket tables. Bands are matched by name first. Bands whose
+// name is derived from their bounds get a new name when a threshold moves, so the bands left unmatched are paired in
+// order of their minimum and reported as changed. Any band left over was added or removed.
+func compareBands(before []entity.TaxBracket, after []entity.TaxBracket) []entity.BandChange {
+	afterByBand := make(map[string]int)
+	for i, bracket := range after {
+		afterByBand[bracket.Band] = i
+	}
+
+	var changes []entity.BandChange
+	matched := make(map[int]bool)
+	var unmatchedBefore []entity.TaxBracket
+	for _, bracket := range before {
+		i, ok := afterByBand[bracket.Band]
+		if !ok {
+			unmatchedBefore = append(unmatchedBefore, bracket)
+			continue
+		}
+
+		matched[i] = true
+		if change, ok := compareBand(bracket, after[i]); ok {
+			changes = append(changes, change)
+		}
+	}
+
+	var unmatchedAfter []entity.TaxBracket
+	for i, bracket := range after {
+		if !matched[i] {
+			unmatchedAfter = append(unmatchedAfter, bracket)
+		}
+	}
+
+	sortByMin(unmatchedBefore)
+	sortByMin(unmatchedAfter)
+	for i := 0; i < len(unmatchedBefore) || i < len(unmatchedAfter); i++ {
+		switch {
+		case i >= len(unmatchedAfter):
+			removed := unmatchedBefore[i]
+			changes = append(changes, entity.BandChange{Band: removed.Band, Change: BandRemoved, Before: &removed})
+		case i >= len(unmatchedBefore):
+			added := unmatchedAfter[i]
+			changes = append(changes, entity.BandChange{Band: added.Band, Change: BandAdded, After: &added})
+		default:
+			change, _ := compareBand(unmatchedBefore[i], unmatchedAfter[i])
+			changes = append(changes, change)
+		}
+	}
+
+	return changes
+}
+
+// compareBand lists the fields that differ between two versions of a band, reporting false when none do.
+func compareBand(before entity.TaxBracket, after entity.TaxBracket) (entity.BandChange, bool) {
+	var fields []string
+	if before.Band != after.Band {
+		fields = append(fields, "band")
+	}
+	if before.Min != after.Min {
+		fields = append(fields, "min")
+	}
+	if before.Max != after.Max {
+		fields = append(fields, "max")
+	}
+	if before.Rate != after.Rate {
+		fields = append(fields, "rate")
+	}
+	if len(fields) == 0 {
+		return entity.BandChange{}, false
+	}
+
+	return entity.BandChange{Band: after.Band, Change: BandChanged, Fields: fields, Before: &before, After: &after}, true
+}
+
+// sortByMin sorts the brackets by their minimum.
+func sortByMin(brackets []entity.TaxBracket) {
+	sort.SliceStable(brackets, func(i, j int) bool {
+		return brackets[i].Min < brackets[j].Min
+	})
+}
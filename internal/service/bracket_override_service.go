@@ -0,0 +1,447 @@
+package service
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/goccy/go-json"
+	"github.com/siparisa/interview-test-server/internal/entity"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+// TaxBracketSourceOverride is the bracket source of the tables published through the admin API.
+const TaxBracketSourceOverride = "override"
+
+// The actions recorded in the bracket audit log.
+const (
+	BracketAuditPublish  = "publish"
+	BracketAuditActivate = "activate"
+	BracketAuditRollback = "rollback"
+)
+
+var (
+	// ErrBracketOverrideNotFound is returned when there is no staged override to preview or activate, or no active
+	// override to roll back.
+	ErrBracketOverrideNotFound = errors.New("bracket override not found")
+
+	// ErrUnsupportedOverrideJurisdiction is returned for overrides of a jurisdiction other than the federal brackets and
+	// the brackets of the supported provinces.
+	ErrUnsupportedOverrideJurisdiction = errors.New("bracket overrides are only supported for the federal and provincial brackets")
+
+	// ErrInvalidOverrideEffectiveDate is returned for an override effective from a date outside its tax year.
+	ErrInvalidOverrideEffectiveDate = errors.New("bracket overrides must take effect within their tax year")
+)
+
+// BracketOverrideOptions configures where the bracket audit log is kept.
+type BracketOverrideOptions struct {
+	// AuditFile is an append-only file of JSON lines every change is written to before it is applied. The overrides
+	// are restored from it on start. When empty, the audit log and the overrides are kept in memory only.
+	AuditFile string
+}
+
+// IBracketOverrideService is an IJurisdictionTaxBracketService whose federal and provincial brackets can be replaced
+// through the admin API. A table is first published as a staged override, previewed, and then activated; rolling back
+// returns to the override active before it, or to the wrapped service.
+type IBracketOverrideService interface {
+	IJurisdictionTaxBracketService
+	PublishTaxBracketOverride(jurisdiction string, taxYear string, effectiveFrom time.Time, taxBrackets []entity.TaxBracket, actor string, reason string) (*entity.BracketOverride, error)
+	PreviewTaxBracketOverride(ctx context.Context, jurisdiction string, taxYear string, salaries []float64) (*entity.BracketImpactReport, error)
+	ActivateTaxBracketOverride(jurisdiction string, taxYear string, actor string) (*entity.BracketOverride, error)
+	RollbackTaxBracketOverride(jurisdiction string, taxYear string, actor string) (*entity.BracketOverrideStatus, error)
+	GetTaxBracketOverride(jurisdiction string, taxYear string) (*entity.BracketOverrideStatus, error)
+	GetBracketAuditLog() []entity.BracketAuditEntry
+}
+
+// bracketOverrideState holds the overrides of a jurisdiction and tax year. Of the active overrides, the last activated
+// one of each effective date is in use from that date on.
+type bracketOverrideState struct {
+	revision int
+	staged   *entity.BracketOverride
+	active   []entity.BracketOverride
+}
+
+type bracketOverrideService struct {
+	next          IJurisdictionTaxBracketService
+	impactService IBracketImpactService
+	options       BracketOverrideOptions
+	logger        *log.Logger
+
+	mu        sync.RWMutex
+	overrides map[string]*bracketOverrideState
+	auditLog  []entity.BracketAuditEntry
+}
+
+// NewBracketOverrideService wraps the given IJurisdictionTaxBracketService with the bracket overrides of the admin API,
+// restoring the overrides from the audit file. Staged overrides are previewed with the IBracketImpactService.
+func NewBracketOverrideService(next IJurisdictionTaxBracketService, impactService IBracketImpactService, options BracketOverrideOptions, logger *log.Logger) (IBracketOverrideService, error) {
+	s := &bracketOverrideService{
+		next:          next,
+		impactService: impactService,
+		options:       options,
+		logger:        logger,
+		overrides:     make(map[string]*bracketOverrideState),
+	}
+
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// GetTaxBracket returns the federal tax brackets for the given year, see GetJurisdictionTaxBracket.
+func (s *bracketOverrideService) GetTaxBracket(ctx context.Context, taxYear string) (*entity.TaxBrackets, error) {
+	return s.GetJurisdictionTaxBracket(ctx, FederalJurisdiction, taxYear)
+}
+
+// GetJurisdictionTaxBracket returns the active override of the jurisdiction and tax year in effect on the date asked
+// for by the context, today by default, or the tax brackets of the wrapped service when there is none. Like the
+// bracket table files, an override effective from the start of the year also applies to earlier dates.
+func (s *bracketOverrideService) GetJurisdictionTaxBracket(ctx context.Context, jurisdiction string, taxYear string) (*entity.TaxBrackets, error) {
+	jurisdiction = normalizeJurisdiction(jurisdiction)
+	asOf := asOfFromContext(ctx)
+	if start := startOfTaxYear(taxYear); asOf.Before(start) {
+		asOf = start
+	}
+
+	// The last activated override of the latest effective date on or before the date is in effect
+	s.mu.RLock()
+	var override *entity.BracketOverride
+	if state := s.overrides[overrideKey(jurisdiction, taxYear)]; state != nil {
+		for i := len(state.active) - 1; i >= 0; i-- {
+			active := &state.active[i]
+			if !active.EffectiveFrom.After(asOf) && (override == nil || active.EffectiveFrom.After(override.EffectiveFrom)) {
+				override = active
+			}
+		}
+	}
+	s.mu.RUnlock()
+
+	if override == nil {
+		return s.next.GetJurisdictionTaxBracket(ctx, jurisdiction, taxYear)
+	}
+
+	return &entity.TaxBrackets{
+		TaxBrackets:   append([]entity.TaxBracket(nil), override.TaxBrackets...),
+		AsOf:          time.Now(),
+		Source:        TaxBracketSourceOverride,
+		EffectiveFrom: override.EffectiveFrom,
+	}, nil
+}
+
+// PublishTaxBracketOverride validates the brackets and stages them as the next override of the jurisdiction and tax
+// year, effective from the given date or from the start of the year when no date is given. Any override staged before
+// is replaced. Invalid brackets are rejected with a *BracketValidationError.
+func (s *bracketOverrideService) PublishTaxBracketOverride(jurisdiction string, taxYear string, effectiveFrom time.Time, taxBrackets []entity.TaxBracket, actor string, reason string) (*entity.BracketOverride, error) {
+	jurisdiction = normalizeJurisdiction(jurisdiction)
+	if !supportedOverrideJurisdiction(jurisdiction) {
+		return nil, ErrUnsupportedOverrideJurisdiction
+	}
+
+	if effectiveFrom.IsZero() {
+		effectiveFrom = startOfTaxYear(taxYear)
+	}
+	if start := startOfTaxYear(taxYear); effectiveFrom.Before(start) || !effectiveFrom.Before(start.AddDate(1, 0, 0)) {
+		return nil, ErrInvalidOverrideEffectiveDate
+	}
+
+	table := &entity.TaxBrackets{TaxBrackets: append([]entity.TaxBracket(nil), taxBrackets...)}
+	if err := ValidateTaxBrackets(table); err != nil {
+		return nil, err
+	}
+	identifyTaxBrackets(table)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry := entity.BracketAuditEntry{
+		Time:          time.Now(),
+		Actor:         actor,
+		Action:        BracketAuditPublish,
+		Jurisdiction:  jurisdiction,
+		TaxYear:       taxYear,
+		Revision:      s.state(jurisdiction, taxYear).revision + 1,
+		Reason:        reason,
+		EffectiveFrom: &effectiveFrom,
+		TaxBrackets:   table.TaxBrackets,
+	}
+	if err := s.audit(entry); err != nil {
+		return nil, err
+	}
+
+	staged := *s.state(jurisdiction, taxYear).staged
+	return &staged, nil
+}
+
+// PreviewTaxBracketOverride reports the impact of activating the staged override on the given salaries, or on the
+// reference salaries when none are given, compared to the brackets in effect on the date it takes effect.
+func (s *bracketOverrideService) PreviewTaxBracketOverride(ctx context.Context, jurisdiction string, taxYear string, salaries []float64) (*entity.BracketImpactReport, error) {
+	status, err := s.GetTaxBracketOverride(jurisdiction, taxYear)
+	if err != nil {
+		return nil, err
+	}
+	if status.Staged == nil {
+		return nil, ErrBracketOverrideNotFound
+	}
+
+	current, err := s.GetJurisdictionTaxBracket(WithAsOf(ctx, status.Staged.EffectiveFrom), status.Jurisdiction, taxYear)
+	if err != nil {
+		return nil, err
+	}
+	staged := &entity.TaxBrackets{TaxBrackets: status.Staged.TaxBrackets}
+
+	report, err := s.impactService.CompareTaxBrackets(taxYear, current, staged, salaries)
+	if err != nil {
+		return nil, err
+	}
+
+	report.Before = fmt.Sprintf("%s brackets in use", current.Source)
+	if current.Source == TaxBracketSourceOverride {
+		report.Before = "active override in use"
+	}
+	report.After = fmt.Sprintf("staged override revision %d", status.Staged.Revision)
+
+	return report, nil
+}
+
+// ActivateTaxBracketOverride puts the staged override of the jurisdiction and tax year in use from its effective date.
+func (s *bracketOverrideService) ActivateTaxBracketOverride(jurisdiction string, taxYear string, actor string) (*entity.BracketOverride, error) {
+	jurisdiction = normalizeJurisdiction(jurisdiction)
+	if !supportedOverrideJurisdiction(jurisdiction) {
+		return nil, ErrUnsupportedOverrideJurisdiction
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	state := s.state(jurisdiction, taxYear)
+	if state.staged == nil {
+		return nil, ErrBracketOverrideNotFound
+	}
+
+	entry := entity.BracketAuditEntry{
+		Time:         time.Now(),
+		Actor:        actor,
+		Action:       BracketAuditActivate,
+		Jurisdiction: jurisdiction,
+		TaxYear:      taxYear,
+		Revision:     state.staged.Revision,
+	}
+	if err := s.audit(entry); err != nil {
+		return nil, err
+	}
+
+	active := state.active[len(state.active)-1]
+	return &active, nil
+}
+
+// RollbackTaxBracketOverride takes the last activated override of the jurisdiction and tax year out of use, returning
+// to the override active before it, or to the wrapped service.
+func (s *bracketOverrideService) RollbackTaxBracketOverride(jurisdiction string, taxYear string, actor string) (*entity.BracketOverrideStatus, error) {
+	jurisdiction = normalizeJurisdiction(jurisdiction)
+	if !supportedOverrideJurisdiction(jurisdiction) {
+		return nil, ErrUnsupportedOverrideJurisdiction
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	state := s.state(jurisdiction, taxYear)
+	if len(state.active) == 0 {
+		return nil, ErrBracketOverrideNotFound
+	}
+
+	entry := entity.BracketAuditEntry{
+		Time:         time.Now(),
+		Actor:        actor,
+		Action:       BracketAuditRollback,
+		Jurisdiction: jurisdiction,
+		TaxYear:      taxYear,
+		Revision:     state.active[len(state.active)-1].Revision,
+	}
+	if err := s.audit(entry); err != nil {
+		return nil, err
+	}
+
+	return s.status(jurisdiction, taxYear), nil
+}
+
+// GetTaxBracketOverride returns the staged and active overrides of the jurisdiction and tax year.
+func (s *bracketOverrideService) GetTaxBracketOverride(jurisdiction string, taxYear string) (*entity.BracketOverrideStatus, error) {
+	jurisdiction = normalizeJurisdiction(jurisdiction)
+	if !supportedOverrideJurisdiction(jurisdiction) {
+		return nil, ErrUnsupportedOverrideJurisdiction
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.status(jurisdiction, taxYear), nil
+}
+
+// GetBracketAuditLog lists every change made to the overrides, oldest first.
+func (s *bracketOverrideService) GetBracketAuditLog() []entity.BracketAuditEntry {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return append([]entity.BracketAuditEntry(nil), s.auditLog...)
+}
+
+// status returns a copy of the overrides of the jurisdiction and tax year. The caller must hold the lock.
+func (s *bracketOverrideService) status(jurisdiction string, taxYear string) *entity.BracketOverrideStatus {
+	status := &entity.BracketOverrideStatus{Jurisdiction: jurisdiction, TaxYear: taxYear}
+
+	state := s.overrides[overrideKey(jurisdiction, taxYear)]
+	if state == nil {
+		return status
+	}
+
+	if state.staged != nil {
+		staged := *state.staged
+		status.Staged = &staged
+	}
+	for i := len(state.active) - 1; i >= 0; i-- {
+		if status.Active == nil {
+			active := state.active[i]
+			status.Active = &active
+			continue
+		}
+		status.Previous = append(status.Previous, state.active[i])
+	}
+
+	return status
+}
+
+// state returns the overrides of the jurisdiction and tax year, creating them when missing. The caller must hold the
+// lock.
+func (s *bracketOverrideService) state(jurisdiction string, taxYear string) *bracketOverrideState {
+	key := overrideKey(jurisdiction, taxYear)
+	if s.overrides[key] == nil {
+		s.overrides[key] = &bracketOverrideState{}
+	}
+	return s.overrides[key]
+}
+
+// audit writes the entry to the audit file and then applies it, so no change is made that is not audited. The caller
+// must hold the lock.
+func (s *bracketOverrideService) audit(entry entity.BracketAuditEntry) error {
+	if err := s.persist(entry); err != nil {
+		return fmt.Errorf("failed to write the bracket audit log: %w", err)
+	}
+
+	if err := s.apply(entry); err != nil {
+		return err
+	}
+
+	s.logger.Printf("%s by %s of the %s %s tax brackets, revision %d", entry.Action, entry.Actor, entry.Jurisdiction, entry.TaxYear, entry.Revision)
+	return nil
+}
+
+// apply makes the change recorded in the audit entry. The caller must hold the lock.
+func (s *bracketOverrideService) apply(entry entity.BracketAuditEntry) error {
+	state := s.state(entry.Jurisdiction, entry.TaxYear)
+
+	switch entry.Action {
+	case BracketAuditPublish:
+		// Overrides published before they were effective-dated apply from the start of the year
+		effectiveFrom := startOfTaxYear(entry.TaxYear)
+		if entry.EffectiveFrom != nil {
+			effectiveFrom = *entry.EffectiveFrom
+		}
+
+		state.revision = entry.Revision
+		state.staged = &entity.BracketOverride{
+			Jurisdiction:  entry.Jurisdiction,
+			TaxYear:       entry.TaxYear,
+			Revision:      entry.Revision,
+			Reason:        entry.Reason,
+			PublishedBy:   entry.Actor,
+			PublishedAt:   entry.Time,
+			EffectiveFrom: effectiveFrom,
+			TaxBrackets:   entry.TaxBrackets,
+		}
+	case BracketAuditActivate:
+		if state.staged == nil || state.staged.Revision != entry.Revision {
+			return fmt.Errorf("revision %d of the %s %s tax brackets is not staged", entry.Revision, entry.Jurisdiction, entry.TaxYear)
+		}
+		state.active = append(state.active, *state.staged)
+		state.staged = nil
+	case BracketAuditRollback:
+		if len(state.active) == 0 || state.active[len(state.active)-1].Revision != entry.Revision {
+			return fmt.Errorf("revision %d of the %s %s tax brackets is not active", entry.Revision, entry.Jurisdiction, entry.TaxYear)
+		}
+		state.active = state.active[:len(state.active)-1]
+	default:
+		return fmt.Errorf("unknown bracket audit action %q", entry.Action)
+	}
+
+	s.auditLog = append(s.auditLog, entry)
+	return nil
+}
+
+// load restores the overrides by applying the entries of the audit file, if any.
+func (s *bracketOverrideService) load() error {
+	if s.options.AuditFile == "" {
+		return nil
+	}
+
+	file, err := os.Open(s.options.AuditFile)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		var entry entity.BracketAuditEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return fmt.Errorf("invalid bracket audit log line %d: %w", line, err)
+		}
+		entry.Jurisdiction = normalizeJurisdiction(entry.Jurisdiction)
+		if err := s.apply(entry); err != nil {
+			return fmt.Errorf("invalid bracket audit log line %d: %w", line, err)
+		}
+	}
+
+	return scanner.Err()
+}
+
+// persist appends an entry to the audit file, if any.
+func (s *bracketOverrideService) persist(entry entity.BracketAuditEntry) error {
+	if s.options.AuditFile == "" {
+		return nil
+	}
+
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(s.options.AuditFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	_, err = file.Write(append(line, '\n'))
+	return err
+}
+
+// supportedOverrideJurisdiction reports whether the normalized jurisdiction is federal or a supported province.
+func supportedOverrideJurisdiction(jurisdiction string) bool {
+	_, ok := provincialTaxRulesByYear[jurisdiction]
+	return ok || jurisdiction == FederalJurisdiction
+}
+
+// overrideKey identifies the overrides of a normalized jurisdiction and tax year.
+func overrideKey(jurisdiction string, taxYear string) string {
+	return jurisdiction + "/" + taxYear
+}
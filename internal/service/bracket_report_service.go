@@ -0,0 +1,156 @@
+package service
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"github.com/goccy/go-json"
+	"github.com/siparisa/interview-test-server/internal/entity"
+	"log"
+	"os"
+	"reflect"
+	"sync"
+)
+
+// BracketReportOptions configures where the impact reports are kept.
+type BracketReportOptions struct {
+	// File is an append-only file of JSON lines the reports are written to, so they survive restarts. When empty, the
+	// reports are kept in memory only.
+	File string
+}
+
+// IBracketReportService is an IBracketImpactService that keeps an impact report of every bracket table replaced by a
+// newer one, whether the bracket table files, the cache or the bracket history notices the change.
+type IBracketReportService interface {
+	IBracketImpactService
+	ReportTaxBracketChange(jurisdiction string, taxYear string, before *entity.TaxBrackets, after *entity.TaxBrackets)
+	GetBracketImpactReports(jurisdiction string, taxYear string) []entity.BracketImpactReport
+}
+
+type bracketReportService struct {
+	IBracketImpactService
+	options BracketReportOptions
+	logger  *log.Logger
+
+	mu      sync.Mutex
+	reports map[string][]entity.BracketImpactReport // by jurisdiction and year, see historyKey
+}
+
+// NewBracketReportService creates a new instance of the bracketReportService reporting the impact of bracket table
+// changes with the given IBracketImpactService, loading the reports generated before from the report file.
+func NewBracketReportService(impactService IBracketImpactService, options BracketReportOptions, logger *log.Logger) (IBracketReportService, error) {
+	s := &bracketReportService{
+		IBracketImpactService: impactService,
+		options:               options,
+		logger:                logger,
+		reports:               make(map[string][]entity.BracketImpactReport),
+	}
+
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// ReportTaxBracketChange generates and keeps the impact report of the brackets of the jurisdiction and tax year going
+// from one table to another. A change already reported last, e.g. by the bracket table files before the history
+// records the new table, is not reported twice.
+func (s *bracketReportService) ReportTaxBracketChange(jurisdiction string, taxYear string, before *entity.TaxBrackets, after *entity.TaxBrackets) {
+	jurisdiction = normalizeJurisdiction(jurisdiction)
+	key := historyKey(jurisdiction, taxYear)
+
+	report, err := s.CompareTaxBrackets(taxYear, before, after, nil)
+	if err != nil {
+		s.logger.Printf("failed to report the impact of the new %s tax brackets from %s: %v", key, after.Source, err)
+		return
+	}
+	report.Jurisdiction = jurisdiction
+	report.Before = describeTaxBrackets(before)
+	report.After = describeTaxBrackets(after)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	reports := s.reports[key]
+	if len(reports) > 0 && reflect.DeepEqual(reports[len(reports)-1].BandChanges, report.BandChanges) {
+		return
+	}
+	s.reports[key] = append(reports, *report)
+
+	if err := s.persist(*report); err != nil {
+		s.logger.Printf("failed to write the impact report of the %s tax brackets: %v", key, err)
+	}
+	s.logger.Printf("%s of the %s tax brackets replaces %s: %d bands changed", report.After, key, report.Before, len(report.BandChanges))
+}
+
+// GetBracketImpactReports lists the impact reports of the brackets of the jurisdiction and tax year, oldest first.
+func (s *bracketReportService) GetBracketImpactReports(jurisdiction string, taxYear string) []entity.BracketImpactReport {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]entity.BracketImpactReport(nil), s.reports[historyKey(normalizeJurisdiction(jurisdiction), taxYear)]...)
+}
+
+// load reads the reports kept in the report file, if any.
+func (s *bracketReportService) load() error {
+	if s.options.File == "" {
+		return nil
+	}
+
+	file, err := os.Open(s.options.File)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		var report entity.BracketImpactReport
+		if err := json.Unmarshal(scanner.Bytes(), &report); err != nil {
+			return fmt.Errorf("invalid impact report line %d: %w", line, err)
+		}
+		key := historyKey(normalizeJurisdiction(report.Jurisdiction), report.TaxYear)
+		s.reports[key] = append(s.reports[key], report)
+	}
+
+	return scanner.Err()
+}
+
+// persist appends a report to the report file, if any. The caller must hold the lock.
+func (s *bracketReportService) persist(report entity.BracketImpactReport) error {
+	if s.options.File == "" {
+		return nil
+	}
+
+	line, err := json.Marshal(report)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(s.options.File, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	_, err = file.Write(append(line, '\n'))
+	return err
+}
+
+// describeTaxBrackets describes a bracket table in an impact report, by its recorded version when it has one.
+func describeTaxBrackets(taxBrackets *entity.TaxBrackets) string {
+	if taxBrackets.Version > 0 {
+		return describeVersion(taxBrackets.Version, taxBrackets.Source)
+	}
+
+	description := fmt.Sprintf("%s brackets effective %s", taxBrackets.Source, taxBrackets.EffectiveFrom.Format("2006-01-02"))
+	if !taxBrackets.AsOf.IsZero() {
+		description += " as of " + taxBrackets.AsOf.Format("2006-01-02 15:04:05")
+	}
+	return description
+}
@@ -0,0 +1,75 @@
+package service
+
+import (
+	"fmt"
+	"github.com/siparisa/interview-test-server/internal/entity"
+	"sort"
+	"strings"
+)
+
+// BracketValidationError lists every problem found in a bracket table.
+type BracketValidationError struct {
+	Problems []string
+}
+
+func (e *BracketValidationError) Error() string {
+	return "invalid tax brackets: " + strings.Join(e.Problems, "; ")
+}
+
+// ValidateTaxBrackets sorts the brackets by their minimum and checks that they form a single schedule: the bands
+// start at zero, each band ends where the next one starts, the rates are between 0 and 1, only the top band is
+// open-ended and no band name is used twice. All problems found are returned together in a *BracketValidationError.
+func ValidateTaxBrackets(taxBrackets *entity.TaxBrackets) error {
+	brackets := taxBrackets.TaxBrackets
+	if len(brackets) == 0 {
+		return &BracketValidationError{Problems: []string{"no tax brackets"}}
+	}
+
+	sort.SliceStable(brackets, func(i, j int) bool {
+		return brackets[i].Min < brackets[j].Min
+	})
+
+	var problems []string
+	bands := make(map[string]bool)
+	if brackets[0].Min != 0 {
+		problems = append(problems, fmt.Sprintf("first bracket starts at %v instead of 0", brackets[0].Min))
+	}
+
+	for i, bracket := range brackets {
+		if bracket.Band != "" {
+			if bands[bracket.Band] {
+				problems = append(problems, fmt.Sprintf("band %q is used more than once", bracket.Band))
+			}
+			bands[bracket.Band] = true
+		}
+
+		if bracket.Rate < 0 || bracket.Rate > 1 {
+			problems = append(problems, fmt.Sprintf("bracket starting at %v has rate %v outside of 0 to 1", bracket.Min, bracket.Rate))
+		}
+
+		if i == len(brackets)-1 {
+			if bracket.Max != 0 {
+				problems = append(problems, fmt.Sprintf("top bracket ends at %v instead of being open-ended", bracket.Max))
+			}
+			continue
+		}
+
+		if bracket.Max <= bracket.Min {
+			problems = append(problems, fmt.Sprintf("bracket starting at %v has no max above its min", bracket.Min))
+			continue
+		}
+
+		next := brackets[i+1]
+		if next.Min > bracket.Max {
+			problems = append(problems, fmt.Sprintf("gap between %v and %v", bracket.Max, next.Min))
+		} else if next.Min < bracket.Max {
+			problems = append(problems, fmt.Sprintf("brackets overlap between %v and %v", next.Min, bracket.Max))
+		}
+	}
+
+	if len(problems) > 0 {
+		return &BracketValidationError{Problems: problems}
+	}
+
+	return nil
+}
@@ -0,0 +1,202 @@
+package service
+
+import (
+	"context"
+	"github.com/siparisa/interview-test-server/internal/entity"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// DefaultCacheTTL is how long the brackets of the current and future tax years are cached when no TTL is configured.
+	DefaultCacheTTL = time.Hour
+	// DefaultMaxStaleness is how long expired brackets keep being served when no maximum staleness is configured.
+	DefaultMaxStaleness = 24 * time.Hour
+)
+
+// CacheOptions configures how long retrieved tax brackets are kept.
+type CacheOptions struct {
+	// TTL applies to the current and future tax years, whose brackets can still be amended.
+	TTL time.Duration
+	// ClosedYearTTL applies to past tax years. Zero keeps their brackets for the lifetime of the process.
+	ClosedYearTTL time.Duration
+	// MaxStaleness is how long after expiring the brackets are still served while they are refreshed in the
+	// background, including while the upstream is failing.
+	MaxStaleness time.Duration
+}
+
+// ICachedTaxBracketService is an ITaxBracketService with an in-memory cache that reports its hit and miss counts.
+type ICachedTaxBracketService interface {
+	ITaxBracketService
+	GetCacheStats() entity.CacheStats
+}
+
+// cacheEntry holds the brackets retrieved for a year and when they expire. A zero expiry never expires.
+type cacheEntry struct {
+	taxBrackets *entity.TaxBrackets
+	fetchedAt   time.Time
+	expiresAt   time.Time
+}
+
+type cachedTaxBracketService struct {
+	next          ITaxBracketService
+	reportService IBracketReportService
+	options       CacheOptions
+
+	mu      sync.RWMutex
+	entries map[string]cacheEntry
+	group   callGroup
+
+	hits            int64
+	misses          int64
+	coalesced       int64
+	staleServed     int64
+	refreshFailures int64
+}
+
+// NewCachedTaxBracketService wraps the given ITaxBracketService with a per-year cache. Concurrent misses for the same
+// year are coalesced into a single upstream fetch. The impact of every cached table a fetch replaces with a changed
+// one is reported to the IBracketReportService, if any.
+func NewCachedTaxBracketService(next ITaxBracketService, reportService IBracketReportService, options CacheOptions) ICachedTaxBracketService {
+	if options.TTL <= 0 {
+		options.TTL = DefaultCacheTTL
+	}
+	if options.MaxStaleness <= 0 {
+		options.MaxStaleness = DefaultMaxStaleness
+	}
+
+	return &cachedTaxBracketService{
+		next:          next,
+		reportService: reportService,
+		options:       options,
+		entries:       make(map[string]cacheEntry),
+	}
+}
+
+// GetTaxBracket retrieves the tax brackets from the cache, fetching them from the wrapped service on a miss. Expired
+// brackets within the maximum staleness are served marked as stale while they are refreshed in the background.
+func (s *cachedTaxBracketService) GetTaxBracket(ctx context.Context, taxYear string) (*entity.TaxBrackets, error) {
+	now := time.Now()
+	if entry, ok := s.lookup(taxYear); ok {
+		if !entry.expired(now) {
+			atomic.AddInt64(&s.hits, 1)
+			return copyTaxBrackets(entry.taxBrackets), nil
+		}
+
+		if now.Before(entry.expiresAt.Add(s.options.MaxStaleness)) {
+			atomic.AddInt64(&s.staleServed, 1)
+			go s.refresh(ctx, taxYear)
+
+			taxBrackets := copyTaxBrackets(entry.taxBrackets)
+			taxBrackets.Stale = true
+			return taxBrackets, nil
+		}
+	}
+
+	atomic.AddInt64(&s.misses, 1)
+	result, shared, err := s.group.do(ctx, taxYear, func(ctx context.Context) (interface{}, error) {
+		return s.fetch(ctx, taxYear)
+	})
+	if shared {
+		atomic.AddInt64(&s.coalesced, 1)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return copyTaxBrackets(result.(*entity.TaxBrackets)), nil
+}
+
+// GetCacheStats reports the hit and miss counts of the cache.
+func (s *cachedTaxBracketService) GetCacheStats() entity.CacheStats {
+	s.mu.RLock()
+	entries := len(s.entries)
+	s.mu.RUnlock()
+
+	return entity.CacheStats{
+		Hits:            atomic.LoadInt64(&s.hits),
+		Misses:          atomic.LoadInt64(&s.misses),
+		Coalesced:       atomic.LoadInt64(&s.coalesced),
+		Entries:         entries,
+		StaleServed:     atomic.LoadInt64(&s.staleServed),
+		RefreshFailures: atomic.LoadInt64(&s.refreshFailures),
+	}
+}
+
+// refresh fetches the brackets of an expired entry in the background. Concurrent refreshes of the same year share a
+// single fetch, and a failed refresh keeps the stale entry in place.
+func (s *cachedTaxBracketService) refresh(ctx context.Context, taxYear string) {
+	_, _, err := s.group.do(detachedContext{parent: ctx}, taxYear, func(ctx context.Context) (interface{}, error) {
+		return s.fetch(ctx, taxYear)
+	})
+	if err != nil {
+		atomic.AddInt64(&s.refreshFailures, 1)
+	}
+}
+
+// fetch retrieves the brackets from the wrapped service and stores them in the cache, reporting the change when they
+// replace different cached brackets.
+func (s *cachedTaxBracketService) fetch(ctx context.Context, taxYear string) (*entity.TaxBrackets, error) {
+	taxBrackets, err := s.next.GetTaxBracket(ctx, taxYear)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	entry := cacheEntry{
+		taxBrackets: copyTaxBrackets(taxBrackets),
+		fetchedAt:   now,
+	}
+	if entry.taxBrackets.AsOf.IsZero() {
+		entry.taxBrackets.AsOf = now
+	}
+	if ttl := s.ttl(taxYear, now); ttl > 0 {
+		entry.expiresAt = now.Add(ttl)
+	}
+
+	s.mu.Lock()
+	previous, replaced := s.entries[taxYear]
+	s.entries[taxYear] = entry
+	s.mu.Unlock()
+
+	if replaced && s.reportService != nil && !sameTaxBrackets(previous.taxBrackets.TaxBrackets, entry.taxBrackets.TaxBrackets) {
+		s.reportService.ReportTaxBracketChange(FederalJurisdiction, taxYear, copyTaxBrackets(previous.taxBrackets), copyTaxBrackets(entry.taxBrackets))
+	}
+
+	return entry.taxBrackets, nil
+}
+
+func (s *cachedTaxBracketService) lookup(taxYear string) (cacheEntry, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	entry, ok := s.entries[taxYear]
+	return entry, ok
+}
+
+// ttl returns how long the brackets of the year are cached. Past tax years are closed and use the closed-year TTL.
+func (s *cachedTaxBracketService) ttl(taxYear string, now time.Time) time.Duration {
+	year, err := strconv.Atoi(taxYear)
+	if err == nil && year < now.Year() {
+		return s.options.ClosedYearTTL
+	}
+
+	return s.options.TTL
+}
+
+func (e cacheEntry) expired(now time.Time) bool {
+	return !e.expiresAt.IsZero() && now.After(e.expiresAt)
+}
+
+// copyTaxBrackets copies the brackets so callers cannot modify the cached ones.
+func copyTaxBrackets(taxBrackets *entity.TaxBrackets) *entity.TaxBrackets {
+	if taxBrackets == nil {
+		return nil
+	}
+
+	copied := *taxBrackets
+	copied.TaxBrackets = append([]entity.TaxBracket(nil), taxBrackets.TaxBrackets...)
+	return &copied
+}
@@ -0,0 +1,63 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// call is an in-flight or completed fetch shared by every caller of the same key.
+type call struct {
+	done   chan struct{}
+	result interface{}
+	err    error
+}
+
+// callGroup deduplicates concurrent fetches of the same key, so a burst of callers triggers exactly one fetch.
+type callGroup struct {
+	mu    sync.Mutex
+	calls map[string]*call
+}
+
+// do runs fn once for all concurrent callers of the key and reports whether the caller shared another caller's fetch.
+// The fetch runs detached from the callers' cancellation, so a caller that gives up does not fail the others; each
+// caller still stops waiting when its own context is done.
+func (g *callGroup) do(ctx context.Context, key string, fn func(ctx context.Context) (interface{}, error)) (interface{}, bool, error) {
+	g.mu.Lock()
+	if g.calls == nil {
+		g.calls = make(map[string]*call)
+	}
+
+	c, shared := g.calls[key]
+	if !shared {
+		c = &call{done: make(chan struct{})}
+		g.calls[key] = c
+
+		go func() {
+			c.result, c.err = fn(detachedContext{parent: ctx})
+
+			g.mu.Lock()
+			delete(g.calls, key)
+			g.mu.Unlock()
+			close(c.done)
+		}()
+	}
+	g.mu.Unlock()
+
+	select {
+	case <-ctx.Done():
+		return nil, shared, ctx.Err()
+	case <-c.done:
+		return c.result, shared, c.err
+	}
+}
+
+// detachedContext keeps the values of its parent but none of its deadline or cancellation.
+type detachedContext struct {
+	parent context.Context
+}
+
+func (c detachedContext) Deadline() (time.Time, bool)       { return time.Time{}, false }
+func (c detachedContext) Done() <-chan struct{}             { return nil }
+func (c detachedContext) Err() error                        { return nil }
+func (c detachedContext) Value(key interface{}) interface{} { return c.parent.Value(key) }
@@ -0,0 +1,210 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"github.com/siparisa/interview-test-server/internal/entity"
+	"log"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	CircuitClosed   = "closed"
+	CircuitOpen     = "open"
+	CircuitHalfOpen = "half-open"
+)
+
+// ErrCircuitOpen is returned when the circuit breaker is open and no fallback brackets are cached for the year.
+var ErrCircuitOpen = errors.New("tax calculator circuit breaker is open")
+
+// CircuitBreakerOptions configures when the circuit breaker opens and how long it stays open.
+type CircuitBreakerOptions struct {
+	// FailureThreshold is the number of consecutive upstream failures that opens the breaker.
+	FailureThreshold int
+	// CoolDown is how long the breaker stays open before a single trial call is let through.
+	CoolDown time.Duration
+}
+
+// ICircuitBreakerTaxBracketService is an ITaxBracketService guarded by a circuit breaker that reports its state.
+type ICircuitBreakerTaxBracketService interface {
+	ITaxBracketService
+	GetCircuitBreakerStatus() entity.CircuitBreakerStatus
+}
+
+type circuitBreakerTaxBracketService struct {
+	next    ITaxBracketService
+	options CircuitBreakerOptions
+	logger  *log.Logger
+
+	mu                  sync.Mutex
+	state               string
+	consecutiveFailures int
+	openedAt            time.Time
+	lastStateChange     time.Time
+	trialInFlight       bool
+	lastGoodBrackets    map[string]*entity.TaxBrackets
+}
+
+// NewCircuitBreakerTaxBracketService wraps the given ITaxBracketService with a circuit breaker. While the breaker is
+// open, calls fail fast with ErrCircuitOpen or fall back to the last brackets retrieved for the year.
+func NewCircuitBreakerTaxBracketService(next ITaxBracketService, options CircuitBreakerOptions, logger *log.Logger) ICircuitBreakerTaxBracketService {
+	if options.FailureThreshold <= 0 {
+		options.FailureThreshold = 5
+	}
+	if options.CoolDown <= 0 {
+		options.CoolDown = 30 * time.Second
+	}
+
+	return &circuitBreakerTaxBracketService{
+		next:             next,
+		options:          options,
+		logger:           logger,
+		state:            CircuitClosed,
+		lastStateChange:  time.Now(),
+		lastGoodBrackets: make(map[string]*entity.TaxBrackets),
+	}
+}
+
+// GetTaxBracket retrieves the tax brackets through the breaker.
+func (s *circuitBreakerTaxBracketService) GetTaxBracket(ctx context.Context, taxYear string) (*entity.TaxBrackets, error) {
+	if !s.allowRequest() {
+		return s.fallback(taxYear)
+	}
+
+	taxBrackets, err := s.next.GetTaxBracket(ctx, taxYear)
+	s.recordResult(ctx, taxYear, taxBrackets, err)
+	if err != nil {
+		if s.isOpen() && isUpstreamFailure(ctx, err) {
+			if fallback, fallbackErr := s.fallback(taxYear); fallbackErr == nil {
+				return fallback, nil
+			}
+		}
+		return nil, err
+	}
+
+	return taxBrackets, nil
+}
+
+// GetCircuitBreakerStatus reports the current state of the breaker.
+func (s *circuitBreakerTaxBracketService) GetCircuitBreakerStatus() entity.CircuitBreakerStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	status := entity.CircuitBreakerStatus{
+		State:               s.state,
+		ConsecutiveFailures: s.consecutiveFailures,
+		LastStateChange:     s.lastStateChange,
+	}
+	if s.state != CircuitClosed {
+		openedAt := s.openedAt
+		status.OpenedAt = &openedAt
+	}
+	for year := range s.lastGoodBrackets {
+		status.FallbackYears = append(status.FallbackYears, year)
+	}
+	sort.Strings(status.FallbackYears)
+
+	return status
+}
+
+// allowRequest reports whether a call may reach the upstream, moving an open breaker to half-open once the cool-down
+// has passed. Only one trial call is let through while half-open.
+func (s *circuitBreakerTaxBracketService) allowRequest() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch s.state {
+	case CircuitOpen:
+		if time.Since(s.openedAt) < s.options.CoolDown {
+			return false
+		}
+		s.setState(CircuitHalfOpen)
+		s.trialInFlight = true
+		return true
+	case CircuitHalfOpen:
+		if s.trialInFlight {
+			return false
+		}
+		s.trialInFlight = true
+		return true
+	}
+
+	return true
+}
+
+// recordResult updates the breaker with the outcome of an upstream call. Failures caused by the caller, such as a
+// cancelled request or an unknown year, do not count against the upstream.
+func (s *circuitBreakerTaxBracketService) recordResult(ctx context.Context, taxYear string, taxBrackets *entity.TaxBrackets, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.state == CircuitHalfOpen {
+		s.trialInFlight = false
+	}
+
+	if err == nil {
+		s.lastGoodBrackets[taxYear] = taxBrackets
+		s.consecutiveFailures = 0
+		if s.state != CircuitClosed {
+			s.setState(CircuitClosed)
+		}
+		return
+	}
+
+	if !isUpstreamFailure(ctx, err) {
+		return
+	}
+
+	s.consecutiveFailures++
+	if s.state == CircuitHalfOpen || (s.state == CircuitClosed && s.consecutiveFailures >= s.options.FailureThreshold) {
+		s.openedAt = time.Now()
+		s.setState(CircuitOpen)
+	}
+}
+
+// fallback returns the last brackets retrieved for the year while the breaker is open.
+func (s *circuitBreakerTaxBracketService) fallback(taxYear string) (*entity.TaxBrackets, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	taxBrackets, ok := s.lastGoodBrackets[taxYear]
+	if !ok {
+		return nil, ErrCircuitOpen
+	}
+
+	return taxBrackets, nil
+}
+
+func (s *circuitBreakerTaxBracketService) isOpen() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.state == CircuitOpen
+}
+
+// setState changes the state of the breaker and logs the transition. The caller must hold the lock.
+func (s *circuitBreakerTaxBracketService) setState(state string) {
+	s.logger.Printf("Tax calculator circuit breaker changed from %s to %s after %d consecutive failure(s)", s.state, state, s.consecutiveFailures)
+	s.state = state
+	s.lastStateChange = time.Now()
+}
+
+// isUpstreamFailure reports whether the error is the upstream's fault rather than the caller's.
+func isUpstreamFailure(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+
+	var fetchErr *BracketFetchError
+	if errors.As(err, &fetchErr) && fetchErr.Reason == FetchFailureStatus {
+		return fetchErr.StatusCode >= http.StatusInternalServerError || fetchErr.StatusCode == http.StatusTooManyRequests
+	}
+	if errors.As(err, &fetchErr) && fetchErr.Reason == FetchFailureRequest {
+		return false
+	}
+
+	return true
+}
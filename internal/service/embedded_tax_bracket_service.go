@@ -0,0 +1,98 @@
+package service
+
+import (
+	"context"
+	"embed"
+	"errors"
+	"fmt"
+	"github.com/goccy/go-json"
+	"github.com/siparisa/interview-test-server/internal/entity"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// TaxBracketSourceRemote means the brackets were retrieved from the tax calculator API.
+	TaxBracketSourceRemote = "remote"
+	// TaxBracketSourceEmbedded means the brackets were read from the tables shipped inside the binary.
+	TaxBracketSourceEmbedded = "embedded"
+)
+
+// ErrTaxYearNotFound is returned when a bracket source has no brackets for the requested year.
+var ErrTaxYearNotFound = errors.New("tax brackets not found for the given year")
+
+// federalTables holds the published federal bracket tables, one <year>.json file per tax year in the same format as
+// the tax calculator API responses.
+//
+//go:embed tables/federal/*.json
+var federalTables embed.FS
+
+// IEmbeddedTaxBracketService is an ITaxBracketService over the federal bracket tables embedded in the binary.
+type IEmbeddedTaxBracketService interface {
+	ITaxBracketService
+	ITaxYearSource
+}
+
+type embeddedTaxBracketService struct {
+	taxBracketsByYear map[string]entity.TaxBrackets
+}
+
+// NewEmbeddedTaxBracketService creates a new instance of the embeddedTaxBracketService from the federal bracket
+// tables embedded in the binary, so brackets are available without reaching the tax calculator API.
+func NewEmbeddedTaxBracketService() (IEmbeddedTaxBracketService, error) {
+	files, err := federalTables.ReadDir("tables/federal")
+	if err != nil {
+		return nil, err
+	}
+
+	taxBracketsByYear := make(map[string]entity.TaxBrackets, len(files))
+	for _, file := range files {
+		content, err := federalTables.ReadFile(path.Join("tables/federal", file.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		var taxBrackets entity.TaxBrackets
+		if err := json.Unmarshal(content, &taxBrackets); err != nil {
+			return nil, fmt.Errorf("invalid embedded tax brackets %s: %w", file.Name(), err)
+		}
+		if err := ValidateTaxBrackets(&taxBrackets); err != nil {
+			return nil, fmt.Errorf("invalid embedded tax brackets %s: %w", file.Name(), err)
+		}
+		identifyTaxBrackets(&taxBrackets)
+
+		taxBracketsByYear[strings.TrimSuffix(file.Name(), ".json")] = taxBrackets
+	}
+
+	return &embeddedTaxBracketService{
+		taxBracketsByYear: taxBracketsByYear,
+	}, nil
+}
+
+// GetTaxBracket retrieves the embedded tax brackets for the given year.
+func (s *embeddedTaxBracketService) GetTaxBracket(ctx context.Context, taxYear string) (*entity.TaxBrackets, error) {
+	taxBrackets, ok := s.taxBracketsByYear[taxYear]
+	if !ok {
+		return nil, ErrTaxYearNotFound
+	}
+
+	taxBrackets.TaxBrackets = append([]entity.TaxBracket(nil), taxBrackets.TaxBrackets...)
+	taxBrackets.AsOf = time.Now()
+	taxBrackets.Source = TaxBracketSourceEmbedded
+	taxBrackets.EffectiveFrom = startOfTaxYear(taxYear)
+
+	return &taxBrackets, nil
+}
+
+// GetTaxYears lists the tax years of the embedded tables.
+func (s *embeddedTaxBracketService) GetTaxYears(ctx context.Context) ([]string, error) {
+	taxYears := make([]string, 0, len(s.taxBracketsByYear))
+	for taxYear := range s.taxBracketsByYear {
+		taxYears = append(taxYears, taxYear)
+	}
+	sort.Strings(taxYears)
+
+	return taxYears, nil
+}
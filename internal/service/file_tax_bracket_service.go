@@ -0,0 +1,431 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"github.com/goccy/go-json"
+	"github.com/siparisa/interview-test-server/internal/entity"
+	"gopkg.in/yaml.v3"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// TaxBracketSourceFile means the brackets were read from the bracket table directory.
+	TaxBracketSourceFile = "file"
+
+	// FederalJurisdiction is the jurisdiction of the federal brackets served by GetTaxBracket.
+	FederalJurisdiction = "federal"
+
+	// DefaultFilePollInterval is how often the bracket table directory is checked for changes when no interval is
+	// configured.
+	DefaultFilePollInterval = 5 * time.Second
+)
+
+// FileTaxBracketOptions configures where the bracket tables are read from and how often they are reloaded.
+type FileTaxBracketOptions struct {
+	// Dir holds one directory per jurisdiction with one <year>.yaml, <year>.json or <year>.csv file per tax year.
+	// Mid-year amendments are added as <year>@<YYYY-MM-DD>.<ext> files effective from the given date.
+	Dir string
+	// PollInterval is how often the directory is checked for changes.
+	PollInterval time.Duration
+}
+
+// IFileTaxBracketService is an ITaxBracketService backed by a directory of bracket tables that is reloaded when the
+// files change.
+type IFileTaxBracketService interface {
+	ITaxBracketService
+	ITaxYearSource
+	GetJurisdictionTaxBracket(ctx context.Context, jurisdiction string, taxYear string) (*entity.TaxBrackets, error)
+	Close()
+}
+
+// bracketTable is the YAML and JSON format of a bracket table file, the same as the tax calculator API responses.
+type bracketTable struct {
+	TaxBrackets []bracketRow `json:"tax_brackets" yaml:"tax_brackets"`
+}
+
+type bracketRow struct {
+	Band  string  `json:"band" yaml:"band"`
+	Label string  `json:"label" yaml:"label"`
+	Min   float64 `json:"min" yaml:"min"`
+	Max   float64 `json:"max" yaml:"max"`
+	Rate  float64 `json:"rate" yaml:"rate"`
+}
+
+// bracketFile is a loaded bracket table and the modification of the file it was read from.
+type bracketFile struct {
+	key         string
+	taxBrackets entity.TaxBrackets
+	modTime     time.Time
+	size        int64
+}
+
+// errNotBracketFile is returned by parseBracketFilePath for files in the directory that are not bracket tables.
+var errNotBracketFile = errors.New("not a bracket table file")
+
+// rejectedFile is the modification of a file that was rejected, so it is only read again once it changes.
+type rejectedFile struct {
+	modTime time.Time
+	size    int64
+}
+
+type fileTaxBracketService struct {
+	options       FileTaxBracketOptions
+	reportService IBracketReportService
+	logger        *log.Logger
+
+	mu    sync.RWMutex
+	files map[string]bracketFile // by path relative to the directory
+
+	// rejected is only used by reload, which never runs concurrently
+	rejected map[string]rejectedFile // by path relative to the directory
+
+	stop chan struct{}
+	once sync.Once
+}
+
+// NewFileTaxBracketService creates a new instance of the fileTaxBracketService and loads the bracket tables from the
+// directory. The directory is polled for changes until Close is called, and the impact of every table replaced by a
+// changed file is reported to the IBracketReportService, if any.
+func NewFileTaxBracketService(options FileTaxBracketOptions, reportService IBracketReportService, logger *log.Logger) (IFileTaxBracketService, error) {
+	if options.PollInterval <= 0 {
+		options.PollInterval = DefaultFilePollInterval
+	}
+
+	if _, err := os.ReadDir(options.Dir); err != nil {
+		return nil, err
+	}
+
+	s := &fileTaxBracketService{
+		options:       options,
+		reportService: reportService,
+		logger:        logger,
+		files:         make(map[string]bracketFile),
+		rejected:      make(map[string]rejectedFile),
+		stop:          make(chan struct{}),
+	}
+	s.reload()
+
+	go s.watch()
+
+	return s, nil
+}
+
+// GetTaxBracket retrieves the federal tax brackets for the given year.
+func (s *fileTaxBracketService) GetTaxBracket(ctx context.Context, taxYear string) (*entity.TaxBrackets, error) {
+	return s.GetJurisdictionTaxBracket(ctx, FederalJurisdiction, taxYear)
+}
+
+// GetJurisdictionTaxBracket retrieves the tax brackets of the given jurisdiction and year in effect on the date asked
+// for by the context, today by default. Before the first version takes effect, the first version is used.
+func (s *fileTaxBracketService) GetJurisdictionTaxBracket(ctx context.Context, jurisdiction string, taxYear string) (*entity.TaxBrackets, error) {
+	key := bracketFileKey(jurisdiction, taxYear)
+	asOf := asOfFromContext(ctx)
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var first, effective *entity.TaxBrackets
+	for rel := range s.files {
+		file := s.files[rel]
+		if file.key != key {
+			continue
+		}
+
+		taxBrackets := &file.taxBrackets
+		if first == nil || taxBrackets.EffectiveFrom.Before(first.EffectiveFrom) {
+			first = taxBrackets
+		}
+		if !taxBrackets.EffectiveFrom.After(asOf) && (effective == nil || taxBrackets.EffectiveFrom.After(effective.EffectiveFrom)) {
+			effective = taxBrackets
+		}
+	}
+
+	if effective == nil {
+		effective = first
+	}
+	if effective == nil {
+		return nil, ErrTaxYearNotFound
+	}
+
+	return copyTaxBrackets(effective), nil
+}
+
+// GetTaxYears lists the tax years of the federal bracket tables.
+func (s *fileTaxBracketService) GetTaxYears(ctx context.Context) ([]string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	seen := make(map[string]bool)
+	var taxYears []string
+	for _, file := range s.files {
+		taxYear, ok := strings.CutPrefix(file.key, FederalJurisdiction+"/")
+		if ok && !seen[taxYear] {
+			seen[taxYear] = true
+			taxYears = append(taxYears, taxYear)
+		}
+	}
+	sort.Strings(taxYears)
+
+	return taxYears, nil
+}
+
+// Close stops watching the directory.
+func (s *fileTaxBracketService) Close() {
+	s.once.Do(func() {
+		close(s.stop)
+	})
+}
+
+// watch reloads the bracket tables every poll interval until the service is closed.
+func (s *fileTaxBracketService) watch() {
+	ticker := time.NewTicker(s.options.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-ticker.C:
+			s.reload()
+		}
+	}
+}
+
+// reload reads the files that changed since the last load and swaps in the new tables at once. A file that cannot be
+// read or is not a valid bracket table is rejected and the table previously loaded from it is kept. A rejected file is
+// logged once and not read again until it changes. The tables replaced by a changed file are reported once swapped.
+func (s *fileTaxBracketService) reload() {
+	s.mu.RLock()
+	current := s.files
+	s.mu.RUnlock()
+
+	files := make(map[string]bracketFile, len(current))
+	rejected := make(map[string]rejectedFile)
+	var replaced []string
+	changed := false
+
+	err := filepath.WalkDir(s.options.Dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return err
+		}
+
+		rel, key, effectiveFrom, nameErr := parseBracketFilePath(s.options.Dir, path)
+		if errors.Is(nameErr, errNotBracketFile) {
+			return nil
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+
+		previous, loaded := current[rel]
+		if loaded && previous.modTime.Equal(info.ModTime()) && previous.size == info.Size() {
+			files[rel] = previous
+			return nil
+		}
+
+		if previousRejection, ok := s.rejected[rel]; ok && previousRejection.modTime.Equal(info.ModTime()) && previousRejection.size == info.Size() {
+			rejected[rel] = previousRejection
+			if loaded {
+				files[rel] = previous
+			}
+			return nil
+		}
+
+		// A file whose name is not a valid tax year and effective date is rejected like an invalid table
+		var taxBrackets *entity.TaxBrackets
+		err = nameErr
+		if err == nil {
+			taxBrackets, err = readBracketFile(path)
+		}
+		if err != nil {
+			s.logger.Printf("rejected tax bracket file %s: %v", path, err)
+			rejected[rel] = rejectedFile{modTime: info.ModTime(), size: info.Size()}
+			if loaded {
+				files[rel] = previous
+			}
+			return nil
+		}
+
+		taxBrackets.AsOf = info.ModTime()
+		taxBrackets.EffectiveFrom = effectiveFrom
+		files[rel] = bracketFile{key: key, taxBrackets: *taxBrackets, modTime: info.ModTime(), size: info.Size()}
+		if loaded && !sameTaxBrackets(previous.taxBrackets.TaxBrackets, taxBrackets.TaxBrackets) {
+			replaced = append(replaced, rel)
+		}
+		changed = true
+		return nil
+	})
+	if err != nil {
+		s.logger.Printf("failed to reload tax bracket files from %s: %v", s.options.Dir, err)
+		return
+	}
+	s.rejected = rejected
+
+	if !changed && len(files) == len(current) {
+		return
+	}
+
+	s.mu.Lock()
+	s.files = files
+	s.mu.Unlock()
+	s.logger.Printf("loaded %d tax bracket table(s) from %s", len(files), s.options.Dir)
+
+	if s.reportService == nil {
+		return
+	}
+	for _, rel := range replaced {
+		before, after := current[rel], files[rel]
+		jurisdiction, taxYear, _ := strings.Cut(after.key, "/")
+		s.reportService.ReportTaxBracketChange(jurisdiction, taxYear, copyTaxBrackets(&before.taxBrackets), copyTaxBrackets(&after.taxBrackets))
+	}
+}
+
+// readBracketFile reads and validates a bracket table file in the format given by its extension.
+func readBracketFile(path string) (*entity.TaxBrackets, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []bracketRow
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		var table bracketTable
+		err = json.Unmarshal(content, &table)
+		rows = table.TaxBrackets
+	case ".yaml", ".yml":
+		var table bracketTable
+		err = yaml.Unmarshal(content, &table)
+		rows = table.TaxBrackets
+	case ".csv":
+		rows, err = parseBracketCSV(content)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	taxBrackets := &entity.TaxBrackets{Source: TaxBracketSourceFile}
+	for _, row := range rows {
+		taxBrackets.TaxBrackets = append(taxBrackets.TaxBrackets, entity.TaxBracket{
+			Band:  row.Band,
+			Label: row.Label,
+			Min:   row.Min,
+			Max:   row.Max,
+			Rate:  row.Rate,
+		})
+	}
+
+	if err := ValidateTaxBrackets(taxBrackets); err != nil {
+		return nil, err
+	}
+	identifyTaxBrackets(taxBrackets)
+
+	return taxBrackets, nil
+}
+
+// parseBracketCSV parses a bracket table with a header row naming the min, max and rate columns, and optionally the
+// band and label columns. An empty max is the open-ended top band.
+func parseBracketCSV(content []byte) ([]bracketRow, error) {
+	records, err := csv.NewReader(bytes.NewReader(content)).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, nil
+	}
+
+	columns := make(map[string]int)
+	for i, name := range records[0] {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, name := range []string{"min", "max", "rate"} {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("missing %s column", name)
+		}
+	}
+
+	var rows []bracketRow
+	for line, record := range records[1:] {
+		var row bracketRow
+		for name, value := range map[string]*float64{"min": &row.Min, "max": &row.Max, "rate": &row.Rate} {
+			field := strings.TrimSpace(record[columns[name]])
+			if field == "" {
+				continue
+			}
+
+			if *value, err = strconv.ParseFloat(field, 64); err != nil {
+				return nil, fmt.Errorf("line %d: invalid %s %q", line+2, name, field)
+			}
+		}
+		if i, ok := columns["band"]; ok {
+			row.Band = strings.TrimSpace(record[i])
+		}
+		if i, ok := columns["label"]; ok {
+			row.Label = strings.TrimSpace(record[i])
+		}
+
+		rows = append(rows, row)
+	}
+
+	return rows, nil
+}
+
+// parseBracketFilePath derives the jurisdiction and year key and the effective date of a
+// <dir>/<jurisdiction>/<year>.<ext> or <dir>/<jurisdiction>/<year>@<YYYY-MM-DD>.<ext> path. It also returns the path
+// relative to the directory, which identifies the file. Files of another layout or extension are not bracket tables
+// and return errNotBracketFile; a year that is not four digits or a date outside the year is an error.
+func parseBracketFilePath(dir string, path string) (string, string, time.Time, error) {
+	rel, err := filepath.Rel(dir, path)
+	if err != nil {
+		return "", "", time.Time{}, errNotBracketFile
+	}
+	rel = filepath.ToSlash(rel)
+
+	parts := strings.Split(rel, "/")
+	if len(parts) != 2 {
+		return "", "", time.Time{}, errNotBracketFile
+	}
+
+	ext := filepath.Ext(parts[1])
+	switch strings.ToLower(ext) {
+	case ".json", ".yaml", ".yml", ".csv":
+	default:
+		return "", "", time.Time{}, errNotBracketFile
+	}
+
+	taxYear, date, amended := strings.Cut(strings.TrimSuffix(parts[1], ext), "@")
+	if _, err := strconv.Atoi(taxYear); err != nil || len(taxYear) != 4 {
+		return rel, "", time.Time{}, fmt.Errorf("%q is not a tax year", taxYear)
+	}
+
+	effectiveFrom := startOfTaxYear(taxYear)
+	if amended {
+		if effectiveFrom, err = time.Parse("2006-01-02", date); err != nil {
+			return rel, "", time.Time{}, fmt.Errorf("%q is not an effective date formatted as YYYY-MM-DD", date)
+		}
+		if strconv.Itoa(effectiveFrom.Year()) != taxYear {
+			return rel, "", time.Time{}, fmt.Errorf("effective date %s is outside the %s tax year", date, taxYear)
+		}
+	}
+
+	return rel, bracketFileKey(parts[0], taxYear), effectiveFrom, nil
+}
+
+// bracketFileKey is the key of the bracket table of a jurisdiction and year.
+func bracketFileKey(jurisdiction string, taxYear string) string {
+	return strings.ToLower(jurisdiction) + "/" + taxYear
+}
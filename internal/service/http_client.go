@@ -0,0 +1,122 @@
+package service
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+)
+
+const (
+	// DefaultAttemptTimeout limits a single request to the tax calculator API when no timeout is configured.
+	DefaultAttemptTimeout = 5 * time.Second
+	// DefaultOverallDeadline limits all attempts of a request to the tax calculator API when no deadline is configured.
+	DefaultOverallDeadline = 15 * time.Second
+)
+
+// TaxBracketServiceOptions configures how the taxBracketService reaches the tax calculator API.
+type TaxBracketServiceOptions struct {
+	// HTTPClient is used as is when set, and the transport options below are ignored.
+	HTTPClient *http.Client
+
+	AttemptTimeout  time.Duration
+	OverallDeadline time.Duration
+	RetryPolicy     RetryPolicy
+
+	MaxIdleConns        int
+	MaxIdleConnsPerHost int
+	MaxConnsPerHost     int
+
+	// ProxyURL overrides the HTTP_PROXY, HTTPS_PROXY and NO_PROXY environment variables when set.
+	ProxyURL string
+
+	// CACertFile is a PEM bundle trusted in addition to the system roots.
+	CACertFile string
+
+	// ClientCertFile and ClientKeyFile are the PEM client certificate and key presented for mTLS.
+	ClientCertFile string
+	ClientKeyFile  string
+
+	// Authenticator adds credentials to every request when set, see IRequestAuthenticator.
+	Authenticator IRequestAuthenticator
+}
+
+// newHTTPClient creates the HTTP client described by the options.
+func newHTTPClient(options TaxBracketServiceOptions) (*http.Client, error) {
+	if options.HTTPClient != nil {
+		return options.HTTPClient, nil
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+
+	if options.MaxIdleConns > 0 {
+		transport.MaxIdleConns = options.MaxIdleConns
+	}
+	if options.MaxIdleConnsPerHost > 0 {
+		transport.MaxIdleConnsPerHost = options.MaxIdleConnsPerHost
+	}
+	if options.MaxConnsPerHost > 0 {
+		transport.MaxConnsPerHost = options.MaxConnsPerHost
+	}
+
+	if options.ProxyURL != "" {
+		proxyURL, err := url.Parse(options.ProxyURL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid proxy URL: %w", err)
+		}
+		transport.Proxy = http.ProxyURL(proxyURL)
+	}
+
+	tlsConfig, err := newTLSConfig(options)
+	if err != nil {
+		return nil, err
+	}
+	if tlsConfig != nil {
+		transport.TLSClientConfig = tlsConfig
+	}
+
+	return &http.Client{Transport: transport}, nil
+}
+
+// newTLSConfig creates the TLS configuration for the custom CA bundle and client certificate, or nil when neither is set.
+func newTLSConfig(options TaxBracketServiceOptions) (*tls.Config, error) {
+	if options.CACertFile == "" && options.ClientCertFile == "" && options.ClientKeyFile == "" {
+		return nil, nil
+	}
+
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+
+	if options.CACertFile != "" {
+		caCert, err := os.ReadFile(options.CACertFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA bundle: %w", err)
+		}
+
+		rootCAs, err := x509.SystemCertPool()
+		if err != nil {
+			rootCAs = x509.NewCertPool()
+		}
+		if !rootCAs.AppendCertsFromPEM(caCert) {
+			return nil, errors.New("no certificates found in CA bundle")
+		}
+		tlsConfig.RootCAs = rootCAs
+	}
+
+	if options.ClientCertFile != "" || options.ClientKeyFile != "" {
+		if options.ClientCertFile == "" || options.ClientKeyFile == "" {
+			return nil, errors.New("both client certificate and key are required for mTLS")
+		}
+
+		clientCert, err := tls.LoadX509KeyPair(options.ClientCertFile, options.ClientKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{clientCert}
+	}
+
+	return tlsConfig, nil
+}
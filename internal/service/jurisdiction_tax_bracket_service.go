@@ -0,0 +1,61 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"github.com/siparisa/interview-test-server/internal/entity"
+	"strings"
+)
+
+// IJurisdictionTaxBracketService is an ITaxBracketService that also serves the brackets of the provinces.
+type IJurisdictionTaxBracketService interface {
+	ITaxBracketService
+	GetJurisdictionTaxBracket(ctx context.Context, jurisdiction string, taxYear string) (*entity.TaxBrackets, error)
+}
+
+type jurisdictionTaxBracketService struct {
+	federal ITaxBracketService
+	files   IJurisdictionTaxBracketService
+}
+
+// NewJurisdictionTaxBracketService creates a new instance of the jurisdictionTaxBracketService serving the federal
+// brackets of the given ITaxBracketService and the provincial brackets of the bracket table directory, falling back to
+// the provincial rules built into the binary. The directory is optional.
+func NewJurisdictionTaxBracketService(federal ITaxBracketService, files IJurisdictionTaxBracketService) IJurisdictionTaxBracketService {
+	return &jurisdictionTaxBracketService{
+		federal: federal,
+		files:   files,
+	}
+}
+
+// GetTaxBracket retrieves the federal tax brackets for the given year.
+func (s *jurisdictionTaxBracketService) GetTaxBracket(ctx context.Context, taxYear string) (*entity.TaxBrackets, error) {
+	return s.federal.GetTaxBracket(ctx, taxYear)
+}
+
+// GetJurisdictionTaxBracket retrieves the tax brackets of the given jurisdiction and year. The provincial tables of the
+// directory are effective-dated like the federal ones, so the version in effect on the date asked for by the context
+// is used.
+func (s *jurisdictionTaxBracketService) GetJurisdictionTaxBracket(ctx context.Context, jurisdiction string, taxYear string) (*entity.TaxBrackets, error) {
+	if strings.EqualFold(jurisdiction, FederalJurisdiction) {
+		return s.federal.GetTaxBracket(ctx, taxYear)
+	}
+
+	if s.files != nil {
+		taxBrackets, err := s.files.GetJurisdictionTaxBracket(ctx, jurisdiction, taxYear)
+		if !errors.Is(err, ErrTaxYearNotFound) {
+			return taxBrackets, err
+		}
+	}
+
+	rules, err := getProvincialTaxRules(strings.ToUpper(jurisdiction), taxYear)
+	if err != nil {
+		return nil, err
+	}
+
+	return &entity.TaxBrackets{
+		TaxBrackets:   append([]entity.TaxBracket(nil), rules.taxBrackets.TaxBrackets...),
+		Source:        TaxBracketSourceEmbedded,
+		EffectiveFrom: startOfTaxYear(taxYear),
+	}, nil
+}
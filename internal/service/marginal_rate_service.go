@@ -0,0 +1,175 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"github.com/shopspring/decimal"
+	"github.com/siparisa/interview-test-server/internal/entity"
+)
+
+const (
+	federalTaxComponent    = "federalTax"
+	provincialTaxComponent = "provincialTax"
+	payrollComponent       = "payrollContributions"
+	childBenefitComponent  = "canadaChildBenefit"
+	gstCreditComponent     = "gstHstCredit"
+	oasRecoveryComponent   = "oasRecovery"
+)
+
+// IMarginalRateService defines the interface for marginal effective tax rate calculations.
+type IMarginalRateService interface {
+	CalculateMarginalEffectiveRate(ctx context.Context, taxBrackets *entity.TaxBrackets, taxYear string, inputs entity.MarginalRateInputs) (*entity.MarginalRateResult, error)
+}
+
+// incomePosition holds the taxes paid and benefits received at a given income.
+type incomePosition struct {
+	federalTax    decimal.Decimal
+	provincialTax decimal.Decimal
+	payroll       decimal.Decimal
+	childBenefit  decimal.Decimal
+	gstCredit     decimal.Decimal
+	oasRecovery   decimal.Decimal
+}
+
+type marginalRateService struct {
+	taxService                 ITaxService
+	provincialTaxService       IProvincialTaxService
+	payrollContributionService IPayrollContributionService
+	benefitService             IBenefitService
+}
+
+// NewMarginalRateService creates a new instance of the marginalRateService on top of the tax, payroll and benefit
+// services so the marginal rate is calculated with the same rules as the other endpoints.
+func NewMarginalRateService(
+	taxService ITaxService,
+	provincialTaxService IProvincialTaxService,
+	payrollContributionService IPayrollContributionService,
+	benefitService IBenefitService,
+) IMarginalRateService {
+	return &marginalRateService{
+		taxService:                 taxService,
+		provincialTaxService:       provincialTaxService,
+		payrollContributionService: payrollContributionService,
+		benefitService:             benefitService,
+	}
+}
+
+// CalculateMarginalEffectiveRate perturbs the salary by the delta and measures the combined change in tax, payroll
+// contributions and income-tested benefits per dollar earned. Each component is reported as its own rate and the
+// largest one is reported as the primary driver.
+func (s *marginalRateService) CalculateMarginalEffectiveRate(ctx context.Context, taxBrackets *entity.TaxBrackets, taxYear string, inputs entity.MarginalRateInputs) (*entity.MarginalRateResult, error) {
+	if inputs.Delta <= 0 {
+		return nil, errors.New("delta must be greater than zero")
+	}
+	if inputs.MaritalStatus == "" {
+		inputs.MaritalStatus = MaritalStatusSingle
+	}
+
+	before, err := s.calculateIncomePosition(ctx, taxBrackets, taxYear, inputs, inputs.Salary)
+	if err != nil {
+		return nil, err
+	}
+
+	after, err := s.calculateIncomePosition(ctx, taxBrackets, taxYear, inputs, inputs.Salary+inputs.Delta)
+	if err != nil {
+		return nil, err
+	}
+
+	// Taxes and contributions paid increase the rate, benefits received decrease it.
+	changes := []struct {
+		name   string
+		change decimal.Decimal
+	}{
+		{federalTaxComponent, after.federalTax.Sub(before.federalTax)},
+		{provincialTaxComponent, after.provincialTax.Sub(before.provincialTax)},
+		{payrollComponent, after.payroll.Sub(before.payroll)},
+		{childBenefitComponent, before.childBenefit.Sub(after.childBenefit)},
+		{gstCreditComponent, before.gstCredit.Sub(after.gstCredit)},
+		{oasRecoveryComponent, after.oasRecovery.Sub(before.oasRecovery)},
+	}
+
+	delta := decimal.NewFromFloat(inputs.Delta)
+	totalChange := decimal.NewFromFloat(0)
+	result := &entity.MarginalRateResult{
+		Salary: inputs.Salary,
+		Delta:  inputs.Delta,
+	}
+
+	var primaryChange decimal.Decimal
+	for _, c := range changes {
+		totalChange = totalChange.Add(c.change)
+
+		roundedChange, _ := c.change.Round(2).Float64()
+		roundedRate, _ := c.change.Div(delta).Mul(decimal.NewFromFloat(100)).Round(2).Float64()
+		result.Components = append(result.Components, entity.MarginalRateComponent{
+			Name:   c.name,
+			Change: roundedChange,
+			Rate:   roundedRate,
+		})
+
+		if c.change.GreaterThan(primaryChange) {
+			primaryChange = c.change
+			result.PrimaryDriver = c.name
+		}
+	}
+
+	result.MarginalEffectiveRate, _ = totalChange.Div(delta).Mul(decimal.NewFromFloat(100)).Round(2).Float64()
+
+	return result, nil
+}
+
+// calculateIncomePosition calculates the taxes paid and benefits received when the salary is the given income.
+func (s *marginalRateService) calculateIncomePosition(ctx context.Context, taxBrackets *entity.TaxBrackets, taxYear string, inputs entity.MarginalRateInputs, salary float64) (*incomePosition, error) {
+	position := &incomePosition{}
+
+	taxAmountBands, err := s.taxService.CalculateTaxPerBand(taxBrackets, salary)
+	if err != nil {
+		return nil, err
+	}
+	position.federalTax = decimal.NewFromFloat(taxAmountBands.TotalTaxAmount)
+
+	if inputs.Province != "" {
+		abatement, err := s.provincialTaxService.CalculateFederalAbatement(inputs.Province, taxAmountBands.TotalTaxAmount)
+		if err != nil {
+			return nil, err
+		}
+		position.federalTax = position.federalTax.Sub(decimal.NewFromFloat(abatement))
+
+		provincialTax, err := s.provincialTaxService.CalculateProvincialTax(ctx, inputs.Province, taxYear, salary)
+		if err != nil {
+			return nil, err
+		}
+		position.provincialTax = decimal.NewFromFloat(provincialTax.TotalTaxAmount)
+	}
+
+	contributions, err := s.payrollContributionService.CalculatePayrollContributions(inputs.Province, taxYear, salary)
+	if err != nil {
+		return nil, err
+	}
+	position.payroll = decimal.NewFromFloat(contributions.TotalContributionAmount)
+
+	familyNetIncome, err := s.benefitService.CalculateFamilyNetIncome(salary, inputs.SpouseSalary)
+	if err != nil {
+		return nil, err
+	}
+
+	benefits, err := s.benefitService.EstimateBenefits(taxYear, entity.BenefitInputs{
+		FamilyNetIncome: familyNetIncome,
+		MaritalStatus:   inputs.MaritalStatus,
+		ChildrenUnder6:  inputs.ChildrenUnder6,
+		Children6To17:   inputs.Children6To17,
+	})
+	if err != nil {
+		return nil, err
+	}
+	position.childBenefit = decimal.NewFromFloat(benefits.CanadaChildBenefit)
+	position.gstCredit = decimal.NewFromFloat(benefits.GSTHSTCredit)
+
+	oasRecovery, err := s.benefitService.CalculateOASRecovery(taxYear, salary+inputs.OASIncome, inputs.OASIncome)
+	if err != nil {
+		return nil, err
+	}
+	position.oasRecovery = decimal.NewFromFloat(oasRecovery)
+
+	return position, nil
+}
@@ -0,0 +1,108 @@
+package service
+
+import (
+	"fmt"
+	"github.com/shopspring/decimal"
+	"github.com/siparisa/interview-test-server/internal/entity"
+)
+
+// IPayrollContributionService defines the interface for payroll contribution calculations.
+type IPayrollContributionService interface {
+	CalculatePayrollContributions(province string, taxYear string, salary float64) (*entity.PayrollContributions, error)
+}
+
+// contributionRules holds the rate, exemption and maximum insurable earnings of a single payroll contribution.
+type contributionRules struct {
+	name        string
+	rate        float64
+	exemption   float64
+	maxEarnings float64
+}
+
+// payrollContributionRulesByYear holds the contributions paid outside Quebec (CPP and EI).
+var payrollContributionRulesByYear = map[string][]contributionRules{
+	"2019": {
+		{name: "CPP", rate: 0.051, exemption: 3500, maxEarnings: 57400},
+		{name: "EI", rate: 0.0162, maxEarnings: 53100},
+	},
+	"2020": {
+		{name: "CPP", rate: 0.0525, exemption: 3500, maxEarnings: 58700},
+		{name: "EI", rate: 0.0158, maxEarnings: 54200},
+	},
+	"2021": {
+		{name: "CPP", rate: 0.0545, exemption: 3500, maxEarnings: 61600},
+		{name: "EI", rate: 0.0158, maxEarnings: 56300},
+	},
+	"2022": {
+		{name: "CPP", rate: 0.057, exemption: 3500, maxEarnings: 64900},
+		{name: "EI", rate: 0.0158, maxEarnings: 60300},
+	},
+}
+
+// quebecPayrollContributionRulesByYear holds the contributions paid in Quebec (QPP, EI at the reduced Quebec rate
+// and QPIP).
+var quebecPayrollContributionRulesByYear = map[string][]contributionRules{
+	"2019": {
+		{name: "QPP", rate: 0.0555, exemption: 3500, maxEarnings: 57400},
+		{name: "EI", rate: 0.0125, maxEarnings: 53100},
+		{name: "QPIP", rate: 0.00526, maxEarnings: 76500},
+	},
+	"2020": {
+		{name: "QPP", rate: 0.057, exemption: 3500, maxEarnings: 58700},
+		{name: "EI", rate: 0.012, maxEarnings: 54200},
+		{name: "QPIP", rate: 0.00494, maxEarnings: 78500},
+	},
+	"2021": {
+		{name: "QPP", rate: 0.059, exemption: 3500, maxEarnings: 61600},
+		{name: "EI", rate: 0.0118, maxEarnings: 56300},
+		{name: "QPIP", rate: 0.00494, maxEarnings: 83500},
+	},
+	"2022": {
+		{name: "QPP", rate: 0.0615, exemption: 3500, maxEarnings: 64900},
+		{name: "EI", rate: 0.012, maxEarnings: 60300},
+		{name: "QPIP", rate: 0.00494, maxEarnings: 88000},
+	},
+}
+
+type payrollContributionService struct{}
+
+// NewPayrollContributionService creates a new instance of the payrollContributionService.
+func NewPayrollContributionService() IPayrollContributionService {
+	return &payrollContributionService{}
+}
+
+// CalculatePayrollContributions calculates the employee payroll contributions on the given salary. Quebec residents
+// contribute to QPP and QPIP instead of CPP and pay EI at the reduced Quebec rate.
+func (s *payrollContributionService) CalculatePayrollContributions(province string, taxYear string, salary float64) (*entity.PayrollContributions, error) {
+	rulesByYear := payrollContributionRulesByYear
+	if province == "QC" {
+		rulesByYear = quebecPayrollContributionRulesByYear
+	}
+
+	rules, ok := rulesByYear[taxYear]
+	if !ok {
+		return nil, fmt.Errorf("payroll contribution rules for %s: %w", taxYear, ErrTaxYearRulesNotFound)
+	}
+
+	contributions := &entity.PayrollContributions{}
+	totalContributionAmount := decimal.NewFromFloat(0)
+
+	for _, rule := range rules {
+		contributoryEarnings := decimal.Min(decimal.NewFromFloat(salary), decimal.NewFromFloat(rule.maxEarnings)).
+			Sub(decimal.NewFromFloat(rule.exemption))
+		if contributoryEarnings.LessThan(decimal.NewFromFloat(0)) {
+			contributoryEarnings = decimal.NewFromFloat(0)
+		}
+
+		roundedAmount, _ := contributoryEarnings.Mul(decimal.NewFromFloat(rule.rate)).Round(2).Float64()
+		contributions.Contributions = append(contributions.Contributions, entity.PayrollContribution{
+			Name:   rule.name,
+			Amount: roundedAmount,
+		})
+		totalContributionAmount = totalContributionAmount.Add(decimal.NewFromFloat(roundedAmount))
+	}
+
+	contributions.TotalContributionAmount, _ = totalContributionAmount.Round(2).Float64()
+
+	return contributions, nil
+}
@@ -0,0 +1,279 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/shopspring/decimal"
+	"github.com/siparisa/interview-test-server/internal/entity"
+)
+
+// ErrUnsupportedProvince is returned when no provincial rules exist for the requested province.
+var ErrUnsupportedProvince = errors.New("unsupported province")
+
+// IProvincialTaxService defines the interface for provincial tax calculations.
+type IProvincialTaxService interface {
+	GetProvincialTaxBrackets(ctx context.Context, province string, taxYear string) (*entity.TaxBrackets, error)
+	CalculateProvincialTax(ctx context.Context, province string, taxYear string, salary float64) (*entity.ProvincialTaxResult, error)
+	CalculateFederalAbatement(province string, basicFederalTax float64) (float64, error)
+}
+
+// provincialTaxRules holds the provincial rules published for a tax year. The brackets are the ones in effect from the
+// start of the year, a bracket source may serve amended ones.
+type provincialTaxRules struct {
+	taxBrackets         entity.TaxBrackets
+	basicPersonalAmount float64
+	surtaxTiers         []surtaxTier
+	healthPremiumSteps  []healthPremiumStep
+}
+
+// surtaxTier is a share of basic provincial tax above a threshold added as surtax.
+type surtaxTier struct {
+	threshold float64
+	rate      float64
+}
+
+// healthPremiumStep is a step of a health premium schedule. Income above the threshold pays the base amount plus the
+// rate on the excess, up to the maximum of the step.
+type healthPremiumStep struct {
+	threshold float64
+	base      float64
+	rate      float64
+	max       float64
+}
+
+// ontarioHealthPremiumSteps holds the Ontario Health Premium schedule, unchanged since it was introduced.
+var ontarioHealthPremiumSteps = []healthPremiumStep{
+	{threshold: 20000, base: 0, rate: 0.06, max: 300},
+	{threshold: 36000, base: 300, rate: 0.06, max: 450},
+	{threshold: 48000, base: 450, rate: 0.25, max: 600},
+	{threshold: 72000, base: 600, rate: 0.25, max: 750},
+	{threshold: 200000, base: 750, rate: 0.25, max: 900},
+}
+
+var ontarioRates = []float64{0.0505, 0.0915, 0.1116, 0.1216, 0.1316}
+
+// federalAbatementRates holds the refundable abatement of basic federal tax granted to residents of a province.
+var federalAbatementRates = map[string]float64{
+	"QC": 0.165,
+}
+
+var provincialTaxRulesByYear = map[string]map[string]provincialTaxRules{
+	"ON": {
+		"2019": {
+			taxBrackets:         newTaxBrackets([]float64{43906, 87813, 150000, 220000}, ontarioRates),
+			basicPersonalAmount: 10582,
+			surtaxTiers:         []surtaxTier{{threshold: 4740, rate: 0.20}, {threshold: 6067, rate: 0.36}},
+			healthPremiumSteps:  ontarioHealthPremiumSteps,
+		},
+		"2020": {
+			taxBrackets:         newTaxBrackets([]float64{44740, 89482, 150000, 220000}, ontarioRates),
+			basicPersonalAmount: 10783,
+			surtaxTiers:         []surtaxTier{{threshold: 4830, rate: 0.20}, {threshold: 6182, rate: 0.36}},
+			healthPremiumSteps:  ontarioHealthPremiumSteps,
+		},
+		"2021": {
+			taxBrackets:         newTaxBrackets([]float64{45142, 90287, 150000, 220000}, ontarioRates),
+			basicPersonalAmount: 10880,
+			surtaxTiers:         []surtaxTier{{threshold: 4874, rate: 0.20}, {threshold: 6237, rate: 0.36}},
+			healthPremiumSteps:  ontarioHealthPremiumSteps,
+		},
+		"2022": {
+			taxBrackets:         newTaxBrackets([]float64{46226, 92454, 150000, 220000}, ontarioRates),
+			basicPersonalAmount: 11141,
+			surtaxTiers:         []surtaxTier{{threshold: 4991, rate: 0.20}, {threshold: 6387, rate: 0.36}},
+			healthPremiumSteps:  ontarioHealthPremiumSteps,
+		},
+	},
+	"QC": {
+		"2019": {
+			taxBrackets:         newTaxBrackets([]float64{43790, 87575, 106555}, []float64{0.15, 0.20, 0.24, 0.2575}),
+			basicPersonalAmount: 15269,
+		},
+		"2020": {
+			taxBrackets:         newTaxBrackets([]float64{44545, 89080, 108390}, []float64{0.15, 0.20, 0.24, 0.2575}),
+			basicPersonalAmount: 15532,
+		},
+		"2021": {
+			taxBrackets:         newTaxBrackets([]float64{45105, 90200, 109755}, []float64{0.15, 0.20, 0.24, 0.2575}),
+			basicPersonalAmount: 15728,
+		},
+		"2022": {
+			taxBrackets:         newTaxBrackets([]float64{46295, 92580, 112655}, []float64{0.15, 0.20, 0.24, 0.2575}),
+			basicPersonalAmount: 16143,
+		},
+	},
+}
+
+type provincialTaxService struct {
+	taxService        ITaxService
+	taxBracketService IJurisdictionTaxBracketService
+}
+
+// NewProvincialTaxService creates a new instance of the provincialTaxService. The provincial brackets are retrieved
+// from the given IJurisdictionTaxBracketService and the bracket pass is delegated to the given ITaxService, so
+// provincial schedules are versioned and calculated the same way as the federal one.
+func NewProvincialTaxService(taxService ITaxService, taxBracketService IJurisdictionTaxBracketService) IProvincialTaxService {
+	return &provincialTaxService{
+		taxService:        taxService,
+		taxBracketService: taxBracketService,
+	}
+}
+
+// GetProvincialTaxBrackets retrieves the provincial tax brackets for the given province and year in effect on the date
+// asked for by the context, or the recorded version it asks for.
+func (s *provincialTaxService) GetProvincialTaxBrackets(ctx context.Context, province string, taxYear string) (*entity.TaxBrackets, error) {
+	if _, ok := provincialTaxRulesByYear[province]; !ok {
+		return nil, ErrUnsupportedProvince
+	}
+
+	return s.taxBracketService.GetJurisdictionTaxBracket(ctx, province, taxYear)
+}
+
+// CalculateProvincialTax calculates the basic provincial tax on the province's own schedule, reduced by the provincial
+// basic personal amount credited at the lowest provincial rate. Any surtax on the basic provincial tax and health
+// premium on income are calculated after the bracket pass and reported as separate line items.
+func (s *provincialTaxService) CalculateProvincialTax(ctx context.Context, province string, taxYear string, salary float64) (*entity.ProvincialTaxResult, error) {
+	rules, err := getProvincialTaxRules(province, taxYear)
+	if err != nil {
+		return nil, err
+	}
+
+	taxBrackets, err := s.GetProvincialTaxBrackets(ctx, province, taxYear)
+	if err != nil {
+		return nil, err
+	}
+
+	taxAmountBands, err := s.taxService.CalculateTaxPerBand(taxBrackets, salary)
+	if err != nil {
+		return nil, err
+	}
+
+	lowestRate := decimal.NewFromFloat(taxBrackets.TaxBrackets[0].Rate)
+	basicPersonalCredit := decimal.NewFromFloat(rules.basicPersonalAmount).Mul(lowestRate)
+
+	basicProvincialTax := decimal.NewFromFloat(taxAmountBands.TotalTaxAmount).Sub(basicPersonalCredit)
+	if basicProvincialTax.LessThan(decimal.NewFromFloat(0)) {
+		basicProvincialTax = decimal.NewFromFloat(0)
+	}
+
+	roundedBasicAmount, _ := basicProvincialTax.Round(2).Float64()
+	surtax := calculateSurtax(rules.surtaxTiers, roundedBasicAmount)
+	healthPremium := calculateHealthPremium(rules.healthPremiumSteps, salary)
+
+	totalTaxAmount := decimal.NewFromFloat(roundedBasicAmount).
+		Add(decimal.NewFromFloat(surtax)).
+		Add(decimal.NewFromFloat(healthPremium))
+
+	roundedCredit, _ := basicPersonalCredit.Round(2).Float64()
+	roundedTotalAmount, _ := totalTaxAmount.Round(2).Float64()
+
+	result := &entity.ProvincialTaxResult{
+		Province:            province,
+		TaxAmountPerBand:    taxAmountBands.TaxAmountPerBand,
+		BasicPersonalCredit: roundedCredit,
+		BasicTaxAmount:      roundedBasicAmount,
+		Surtax:              surtax,
+		HealthPremium:       healthPremium,
+		TotalTaxAmount:      roundedTotalAmount,
+
+		BracketSource:         taxBrackets.Source,
+		BracketsEffectiveFrom: taxBrackets.EffectiveFrom,
+		BracketVersion:        taxBrackets.Version,
+	}
+
+	return result, nil
+}
+
+// CalculateFederalAbatement calculates the refundable abatement of basic federal tax for the given province.
+// Provinces without an abatement return zero.
+func (s *provincialTaxService) CalculateFederalAbatement(province string, basicFederalTax float64) (float64, error) {
+	if _, ok := provincialTaxRulesByYear[province]; !ok {
+		return 0, ErrUnsupportedProvince
+	}
+
+	abatementRate, ok := federalAbatementRates[province]
+	if !ok || basicFederalTax <= 0 {
+		return 0, nil
+	}
+
+	abatement := decimal.NewFromFloat(basicFederalTax).Mul(decimal.NewFromFloat(abatementRate))
+	roundedAmount, _ := abatement.Round(2).Float64()
+
+	return roundedAmount, nil
+}
+
+// calculateSurtax calculates the surtax as the sum of each tier's rate on the basic provincial tax above its threshold.
+func calculateSurtax(tiers []surtaxTier, basicProvincialTax float64) float64 {
+	surtax := decimal.NewFromFloat(0)
+	decimalTax := decimal.NewFromFloat(basicProvincialTax)
+
+	for _, tier := range tiers {
+		threshold := decimal.NewFromFloat(tier.threshold)
+		if decimalTax.GreaterThan(threshold) {
+			surtax = surtax.Add(decimalTax.Sub(threshold).Mul(decimal.NewFromFloat(tier.rate)))
+		}
+	}
+
+	roundedAmount, _ := surtax.Round(2).Float64()
+	return roundedAmount
+}
+
+// calculateHealthPremium calculates the health premium from the highest step whose threshold the income exceeds.
+func calculateHealthPremium(steps []healthPremiumStep, salary float64) float64 {
+	premium := decimal.NewFromFloat(0)
+	decimalSalary := decimal.NewFromFloat(salary)
+
+	for _, step := range steps {
+		threshold := decimal.NewFromFloat(step.threshold)
+		if decimalSalary.LessThanOrEqual(threshold) {
+			break
+		}
+
+		premium = decimal.Min(
+			decimal.NewFromFloat(step.base).Add(decimalSalary.Sub(threshold).Mul(decimal.NewFromFloat(step.rate))),
+			decimal.NewFromFloat(step.max),
+		)
+	}
+
+	roundedAmount, _ := premium.Round(2).Float64()
+	return roundedAmount
+}
+
+// getProvincialTaxRules finds the provincial rules for the given province and year.
+func getProvincialTaxRules(province string, taxYear string) (provincialTaxRules, error) {
+	rulesByYear, ok := provincialTaxRulesByYear[province]
+	if !ok {
+		return provincialTaxRules{}, ErrUnsupportedProvince
+	}
+
+	rules, ok := rulesByYear[taxYear]
+	if !ok {
+		return provincialTaxRules{}, fmt.Errorf("provincial tax rules for %s in %s: %w", province, taxYear, ErrTaxYearNotFound)
+	}
+
+	return rules, nil
+}
+
+// newTaxBrackets builds tax brackets from the upper thresholds and the rate of each band. The last rate applies to
+// the open-ended top band. The bands are identified by their bounds like the brackets of the bracket sources.
+func newTaxBrackets(thresholds []float64, rates []float64) entity.TaxBrackets {
+	taxBrackets := entity.TaxBrackets{}
+
+	min := 0.0
+	for i, rate := range rates {
+		bracket := entity.TaxBracket{
+			Min:  min,
+			Rate: rate,
+		}
+		if i < len(thresholds) {
+			bracket.Max = thresholds[i]
+			min = thresholds[i]
+		}
+
+		taxBrackets.TaxBrackets = append(taxBrackets.TaxBrackets, bracket)
+	}
+	identifyTaxBrackets(&taxBrackets)
+
+	return taxBrackets
+}
@@ -0,0 +1,67 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"github.com/goccy/go-json"
+	"net/http"
+	"time"
+)
+
+// taxYearIndex is the year index of the tax calculator API.
+type taxYearIndex struct {
+	TaxYears []string `json:"tax_years"`
+}
+
+type remoteTaxYearSource struct {
+	taxYearsURL    string
+	httpClient     *http.Client
+	attemptTimeout time.Duration
+	authenticator  IRequestAuthenticator
+}
+
+// NewRemoteTaxYearSource creates an ITaxYearSource that lists the tax years from the year index of the tax calculator
+// API, reached with the same HTTP client configuration and credentials as the taxBracketService.
+func NewRemoteTaxYearSource(taxYearsURL string, options TaxBracketServiceOptions) (ITaxYearSource, error) {
+	httpClient, err := newHTTPClient(options)
+	if err != nil {
+		return nil, err
+	}
+
+	attemptTimeout := options.AttemptTimeout
+	if attemptTimeout <= 0 {
+		attemptTimeout = DefaultAttemptTimeout
+	}
+
+	return &remoteTaxYearSource{
+		taxYearsURL:    taxYearsURL,
+		httpClient:     httpClient,
+		attemptTimeout: attemptTimeout,
+		authenticator:  options.Authenticator,
+	}, nil
+}
+
+// GetTaxYears retrieves the year index of the tax calculator API in a single attempt limited to the attempt timeout.
+func (s *remoteTaxYearSource) GetTaxYears(ctx context.Context) ([]string, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.attemptTimeout)
+	defer cancel()
+
+	resp, err := doAuthenticated(ctx, s.httpClient, s.authenticator, func() (*http.Request, error) {
+		return http.NewRequestWithContext(ctx, http.MethodGet, s.taxYearsURL, nil)
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %d from the tax year index", resp.StatusCode)
+	}
+
+	var index taxYearIndex
+	if err := json.NewDecoder(resp.Body).Decode(&index); err != nil {
+		return nil, err
+	}
+
+	return index.TaxYears, nil
+}
@@ -0,0 +1,260 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/goccy/go-json"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultAPIKeyHeader is the header the API key is sent in when no header is configured.
+	DefaultAPIKeyHeader = "X-API-Key"
+
+	// HMACTimestampHeader is the header of the Unix time an HMAC signed request was signed at.
+	HMACTimestampHeader = "X-Signature-Timestamp"
+
+	// oauth2ExpiryMargin is how long before its expiry an OAuth2 access token is replaced, at most a tenth of its
+	// lifetime.
+	oauth2ExpiryMargin = 30 * time.Second
+
+	// defaultOAuth2TokenLifetime is how long an OAuth2 access token is used when the token response has no expiry.
+	defaultOAuth2TokenLifetime = 5 * time.Minute
+)
+
+// ErrUpstreamAuthentication is returned when the credentials for the tax calculator API cannot be obtained.
+var ErrUpstreamAuthentication = errors.New("failed to authenticate to the tax calculator API")
+
+// IRequestAuthenticator adds the credentials of the tax calculator API to its requests.
+type IRequestAuthenticator interface {
+	// Authenticate adds the credentials to the request.
+	Authenticate(ctx context.Context, req *http.Request) error
+	// Refresh discards the credentials after the API rejected them, reporting whether new credentials can be
+	// obtained so the request is worth sending again.
+	Refresh() bool
+}
+
+type apiKeyAuthenticator struct {
+	header string
+	apiKey string
+}
+
+// NewAPIKeyAuthenticator creates an IRequestAuthenticator that sends a static API key in the given header, or in
+// DefaultAPIKeyHeader when no header is given.
+func NewAPIKeyAuthenticator(header string, apiKey string) IRequestAuthenticator {
+	if header == "" {
+		header = DefaultAPIKeyHeader
+	}
+
+	return &apiKeyAuthenticator{
+		header: header,
+		apiKey: apiKey,
+	}
+}
+
+// Authenticate sets the API key header.
+func (a *apiKeyAuthenticator) Authenticate(ctx context.Context, req *http.Request) error {
+	req.Header.Set(a.header, a.apiKey)
+	return nil
+}
+
+// Refresh reports that a static API key cannot be refreshed.
+func (a *apiKeyAuthenticator) Refresh() bool {
+	return false
+}
+
+// OAuth2Options configures the OAuth2 client credentials grant used to obtain access tokens.
+type OAuth2Options struct {
+	TokenURL     string
+	ClientID     string
+	ClientSecret string
+	Scope        string
+}
+
+// oauth2Token is the token response of an OAuth2 token endpoint.
+type oauth2Token struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int    `json:"expires_in"`
+}
+
+type oauth2Authenticator struct {
+	options        OAuth2Options
+	httpClient     *http.Client
+	attemptTimeout time.Duration
+
+	mu          sync.Mutex
+	accessToken string
+	expiresAt   time.Time
+}
+
+// NewOAuth2Authenticator creates an IRequestAuthenticator that sends a bearer token obtained from the token endpoint
+// with the OAuth2 client credentials grant, reached with the same HTTP client configuration as the taxBracketService.
+// The token is reused until shortly before it expires.
+func NewOAuth2Authenticator(oauth2Options OAuth2Options, options TaxBracketServiceOptions) (IRequestAuthenticator, error) {
+	httpClient, err := newHTTPClient(options)
+	if err != nil {
+		return nil, err
+	}
+
+	attemptTimeout := options.AttemptTimeout
+	if attemptTimeout <= 0 {
+		attemptTimeout = DefaultAttemptTimeout
+	}
+
+	return &oauth2Authenticator{
+		options:        oauth2Options,
+		httpClient:     httpClient,
+		attemptTimeout: attemptTimeout,
+	}, nil
+}
+
+// Authenticate sets the bearer token, requesting a new one from the token endpoint when there is none or it is about
+// to expire. Concurrent requests wait for a single token request.
+func (a *oauth2Authenticator) Authenticate(ctx context.Context, req *http.Request) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.accessToken == "" || time.Now().After(a.expiresAt) {
+		if err := a.requestToken(ctx); err != nil {
+			return fmt.Errorf("%w: %v", ErrUpstreamAuthentication, err)
+		}
+	}
+
+	req.Header.Set("Authorization", "Bearer "+a.accessToken)
+	return nil
+}
+
+// Refresh discards the token so the next request obtains a new one.
+func (a *oauth2Authenticator) Refresh() bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.accessToken = ""
+	return true
+}
+
+// requestToken obtains a new access token from the token endpoint. The caller must hold the lock.
+func (a *oauth2Authenticator) requestToken(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, a.attemptTimeout)
+	defer cancel()
+
+	form := url.Values{"grant_type": {"client_credentials"}}
+	if a.options.Scope != "" {
+		form.Set("scope", a.options.Scope)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, a.options.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(url.QueryEscape(a.options.ClientID), url.QueryEscape(a.options.ClientSecret))
+
+	resp, err := a.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %d from the token endpoint", resp.StatusCode)
+	}
+
+	var token oauth2Token
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return err
+	}
+	if token.AccessToken == "" {
+		return errors.New("no access token in the token response")
+	}
+
+	a.accessToken = token.AccessToken
+	a.expiresAt = time.Now().Add(tokenLifetime(token.ExpiresIn))
+	return nil
+}
+
+// tokenLifetime is how long a token that expires in the given number of seconds is used, replacing it shortly before it
+// expires. The expiry is optional in the token response, without one the default lifetime is used.
+func tokenLifetime(expiresIn int) time.Duration {
+	lifetime := time.Duration(expiresIn) * time.Second
+	if lifetime <= 0 {
+		lifetime = defaultOAuth2TokenLifetime
+	}
+
+	margin := oauth2ExpiryMargin
+	if margin > lifetime/10 {
+		margin = lifetime / 10
+	}
+
+	return lifetime - margin
+}
+
+type hmacAuthenticator struct {
+	keyID  string
+	secret []byte
+}
+
+// NewHMACAuthenticator creates an IRequestAuthenticator that signs every request with HMAC-SHA256. The signature covers
+// the method, the path and query, and the Unix time sent in HMACTimestampHeader, each on its own line, and is sent hex
+// encoded as "Authorization: HMAC-SHA256 KeyId=<key ID>, Signature=<signature>".
+func NewHMACAuthenticator(keyID string, secret string) IRequestAuthenticator {
+	return &hmacAuthenticator{
+		keyID:  keyID,
+		secret: []byte(secret),
+	}
+}
+
+// Authenticate signs the request.
+func (a *hmacAuthenticator) Authenticate(ctx context.Context, req *http.Request) error {
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+
+	mac := hmac.New(sha256.New, a.secret)
+	mac.Write([]byte(req.Method + "\n" + req.URL.RequestURI() + "\n" + timestamp))
+	signature := hex.EncodeToString(mac.Sum(nil))
+
+	req.Header.Set(HMACTimestampHeader, timestamp)
+	req.Header.Set("Authorization", fmt.Sprintf("HMAC-SHA256 KeyId=%s, Signature=%s", a.keyID, signature))
+	return nil
+}
+
+// Refresh reports that the signing key cannot be refreshed.
+func (a *hmacAuthenticator) Refresh() bool {
+	return false
+}
+
+// doAuthenticated sends the request built by newRequest with the credentials of the authenticator, if any. When the
+// API answers 401, the authenticator refreshes its credentials and the request is sent once more if that can help.
+func doAuthenticated(ctx context.Context, httpClient *http.Client, authenticator IRequestAuthenticator, newRequest func() (*http.Request, error)) (*http.Response, error) {
+	for refreshed := false; ; refreshed = true {
+		req, err := newRequest()
+		if err != nil {
+			return nil, err
+		}
+
+		if authenticator != nil {
+			if err := authenticator.Authenticate(ctx, req); err != nil {
+				return nil, err
+			}
+		}
+
+		resp, err := httpClient.Do(req)
+		if err != nil {
+			return nil, err
+		}
+
+		if resp.StatusCode != http.StatusUnauthorized || authenticator == nil || refreshed || !authenticator.Refresh() {
+			return resp, nil
+		}
+		resp.Body.Close()
+	}
+}
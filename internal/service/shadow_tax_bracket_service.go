@@ -0,0 +1,172 @@
+package service
+
+import (
+	"context"
+	"github.com/siparisa/interview-test-server/internal/entity"
+	"log"
+	"sync"
+	"time"
+)
+
+// DefaultShadowTimeout is how long the shadow source is given to answer when no timeout is configured.
+const DefaultShadowTimeout = 10 * time.Second
+
+// ShadowOptions configures the shadow bracket source.
+type ShadowOptions struct {
+	// Source is the name of the shadow source, reported in the stats and logs.
+	Source string
+	// Timeout is how long the shadow source is given to answer.
+	Timeout time.Duration
+}
+
+// IShadowTaxBracketService is an ITaxBracketService that cross-checks the brackets it serves against a shadow source
+// and reports the outcome.
+type IShadowTaxBracketService interface {
+	ITaxBracketService
+	GetShadowStats() entity.ShadowStats
+}
+
+type shadowTaxBracketService struct {
+	primary ITaxBracketService
+	shadow  ITaxBracketService
+	options ShadowOptions
+	logger  *log.Logger
+
+	mu       sync.Mutex
+	inFlight map[string]bool
+	stats    entity.ShadowStats
+}
+
+// NewShadowTaxBracketService wraps the primary ITaxBracketService with a cross-check against the shadow one.
+func NewShadowTaxBracketService(primary ITaxBracketService, shadow ITaxBracketService, options ShadowOptions, logger *log.Logger) IShadowTaxBracketService {
+	if options.Timeout <= 0 {
+		options.Timeout = DefaultShadowTimeout
+	}
+
+	return &shadowTaxBracketService{
+		primary:  primary,
+		shadow:   shadow,
+		options:  options,
+		logger:   logger,
+		inFlight: make(map[string]bool),
+		stats: entity.ShadowStats{
+			Source:           options.Source,
+			MismatchedBands:  make(map[string]int64),
+			MismatchedFields: make(map[string]int64),
+		},
+	}
+}
+
+// GetTaxBracket retrieves the tax brackets from the primary service and compares them with the shadow source in the
+// background. The response is never affected by the shadow source. Only one comparison runs per year at a time.
+func (s *shadowTaxBracketService) GetTaxBracket(ctx context.Context, taxYear string) (*entity.TaxBrackets, error) {
+	taxBrackets, err := s.primary.GetTaxBracket(ctx, taxYear)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	compare := !s.inFlight[taxYear]
+	s.inFlight[taxYear] = true
+	s.mu.Unlock()
+
+	if compare {
+		go s.compare(detachedContext{parent: ctx}, taxYear, copyTaxBrackets(taxBrackets))
+	}
+
+	return taxBrackets, nil
+}
+
+// GetShadowStats reports the comparisons made with the shadow source.
+func (s *shadowTaxBracketService) GetShadowStats() entity.ShadowStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stats := s.stats
+	stats.MismatchedBands = make(map[string]int64, len(s.stats.MismatchedBands))
+	for band, count := range s.stats.MismatchedBands {
+		stats.MismatchedBands[band] = count
+	}
+	stats.MismatchedFields = make(map[string]int64, len(s.stats.MismatchedFields))
+	for field, count := range s.stats.MismatchedFields {
+		stats.MismatchedFields[field] = count
+	}
+
+	return stats
+}
+
+// compare retrieves the brackets of the shadow source and compares the normalized tables, recording the outcome.
+func (s *shadowTaxBracketService) compare(ctx context.Context, taxYear string, primary *entity.TaxBrackets) {
+	defer func() {
+		s.mu.Lock()
+		delete(s.inFlight, taxYear)
+		s.mu.Unlock()
+	}()
+
+	ctx, cancel := context.WithTimeout(ctx, s.options.Timeout)
+	defer cancel()
+
+	shadow, err := s.shadow.GetTaxBracket(ctx, taxYear)
+	if err == nil {
+		err = ValidateTaxBrackets(shadow)
+	}
+	if err != nil {
+		s.mu.Lock()
+		s.stats.ShadowFailures++
+		s.mu.Unlock()
+
+		s.logger.Printf("shadow %s bracket source failed for %s: %v", s.options.Source, taxYear, err)
+		return
+	}
+
+	changes := compareBands(normalizeTaxBrackets(primary), normalizeTaxBrackets(shadow))
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.stats.Comparisons++
+	if len(changes) == 0 {
+		s.stats.Matches++
+		return
+	}
+
+	s.stats.Mismatches++
+	for _, change := range changes {
+		// The band is counted by the name it has in the brackets served
+		band := change.Band
+		if change.Before != nil {
+			band = change.Before.Band
+		}
+
+		s.stats.MismatchedBands[taxYear+" "+band]++
+		if change.Change != BandChanged {
+			s.stats.MismatchedFields[change.Change]++
+		}
+		for _, field := range change.Fields {
+			// Normalized bands are named by their bounds, so a renamed band is already counted by its min or max
+			if field != "band" {
+				s.stats.MismatchedFields[field]++
+			}
+		}
+
+		s.logger.Printf("shadow %s bracket source mismatch for %s: band %s %s %v", s.options.Source, taxYear, band, change.Change, change.Fields)
+	}
+	s.stats.LastMismatch = &entity.ShadowMismatch{
+		TaxYear:       taxYear,
+		PrimarySource: primary.Source,
+		ComparedAt:    time.Now(),
+		BandChanges:   changes,
+	}
+}
+
+// normalizeTaxBrackets returns the brackets with the bands named by their bounds, so tables are compared on their
+// bounds and rates rather than the names given by their source.
+func normalizeTaxBrackets(taxBrackets *entity.TaxBrackets) []entity.TaxBracket {
+	normalized := &entity.TaxBrackets{TaxBrackets: make([]entity.TaxBracket, len(taxBrackets.TaxBrackets))}
+	for i, bracket := range taxBrackets.TaxBrackets {
+		normalized.TaxBrackets[i] = entity.TaxBracket{Min: bracket.Min, Max: bracket.Max, Rate: bracket.Rate}
+	}
+	identifyTaxBrackets(normalized)
+
+	return normalized.TaxBrackets
+}
@@ -0,0 +1,229 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/siparisa/interview-test-server/internal/entity"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// TaxBracketSourceRemoteSecondary is the bracket source of the secondary tax calculator API.
+const TaxBracketSourceRemoteSecondary = "remote-secondary"
+
+// BracketSource is a named ITaxBracketService in a source chain, with the time it is given to answer. A zero Timeout
+// leaves the source to the deadline of the request.
+type BracketSource struct {
+	Name    string
+	Service ITaxBracketService
+	Timeout time.Duration
+}
+
+// SourceChainOptions configures when a source of the chain is considered unhealthy and for how long it is skipped.
+type SourceChainOptions struct {
+	// FailureThreshold is the number of consecutive failures after which a source is unhealthy.
+	FailureThreshold int
+	// RetryAfter is how long an unhealthy source is skipped before it is tried again.
+	RetryAfter time.Duration
+}
+
+// ISourceChainTaxBracketService is an ITaxBracketService over an ordered list of bracket sources that reports the
+// health of each source.
+type ISourceChainTaxBracketService interface {
+	ITaxBracketService
+	GetSourceHealth() []entity.BracketSourceHealth
+}
+
+// sourceHealth holds the health of a source of the chain.
+type sourceHealth struct {
+	consecutiveFailures int
+	lastError           string
+	lastSuccess         time.Time
+	unhealthyUntil      time.Time
+}
+
+type sourceChainTaxBracketService struct {
+	sources []BracketSource
+	options SourceChainOptions
+	logger  *log.Logger
+
+	mu     sync.Mutex
+	health map[string]*sourceHealth
+}
+
+// NewSourceChainTaxBracketService creates an ITaxBracketService that asks the given sources in order of priority. The
+// first healthy source returning valid brackets serves the request.
+func NewSourceChainTaxBracketService(sources []BracketSource, options SourceChainOptions, logger *log.Logger) ISourceChainTaxBracketService {
+	if options.FailureThreshold <= 0 {
+		options.FailureThreshold = 3
+	}
+	if options.RetryAfter <= 0 {
+		options.RetryAfter = 30 * time.Second
+	}
+
+	health := make(map[string]*sourceHealth)
+	for _, source := range sources {
+		health[source.Name] = &sourceHealth{}
+	}
+
+	return &sourceChainTaxBracketService{
+		sources: sources,
+		options: options,
+		logger:  logger,
+		health:  health,
+	}
+}
+
+// GetTaxBracket retrieves the tax brackets from the first healthy source that answers with valid brackets, recording
+// the source in the brackets. Unhealthy sources are only tried when no healthy source answered. A cancelled or expired
+// caller context is returned as is. When every source fails, the error of the first source that failed for another
+// reason than not having the year is returned.
+func (s *sourceChainTaxBracketService) GetTaxBracket(ctx context.Context, taxYear string) (*entity.TaxBrackets, error) {
+	var skipped []BracketSource
+	var errs []error
+
+	for _, source := range s.sources {
+		if !s.isHealthy(source.Name) {
+			skipped = append(skipped, source)
+			continue
+		}
+
+		taxBrackets, err := s.ask(ctx, source, taxYear)
+		if err == nil {
+			return taxBrackets, nil
+		}
+		if ctx.Err() != nil {
+			return nil, err
+		}
+		errs = append(errs, err)
+	}
+
+	for _, source := range skipped {
+		taxBrackets, err := s.ask(ctx, source, taxYear)
+		if err == nil {
+			return taxBrackets, nil
+		}
+		if ctx.Err() != nil {
+			return nil, err
+		}
+		errs = append(errs, err)
+	}
+
+	for _, err := range errs {
+		if !isNotFound(err) {
+			return nil, err
+		}
+	}
+	if len(errs) > 0 {
+		return nil, errs[0]
+	}
+
+	return nil, ErrTaxYearNotFound
+}
+
+// GetSourceHealth reports the health of every source, in order of priority.
+func (s *sourceChainTaxBracketService) GetSourceHealth() []entity.BracketSourceHealth {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	var sourceHealth []entity.BracketSourceHealth
+	for _, source := range s.sources {
+		health := s.health[source.Name]
+		status := entity.BracketSourceHealth{
+			Name:                source.Name,
+			Healthy:             !now.Before(health.unhealthyUntil),
+			ConsecutiveFailures: health.consecutiveFailures,
+			LastError:           health.lastError,
+		}
+		if !health.lastSuccess.IsZero() {
+			lastSuccess := health.lastSuccess
+			status.LastSuccess = &lastSuccess
+		}
+		if !status.Healthy {
+			unhealthyUntil := health.unhealthyUntil
+			status.UnhealthyUntil = &unhealthyUntil
+		}
+		sourceHealth = append(sourceHealth, status)
+	}
+
+	return sourceHealth
+}
+
+// ask retrieves and validates the tax brackets of a source within its timeout, updating the health of the source.
+func (s *sourceChainTaxBracketService) ask(ctx context.Context, source BracketSource, taxYear string) (*entity.TaxBrackets, error) {
+	sourceCtx := ctx
+	if source.Timeout > 0 {
+		var cancel context.CancelFunc
+		sourceCtx, cancel = context.WithTimeout(ctx, source.Timeout)
+		defer cancel()
+	}
+
+	taxBrackets, err := source.Service.GetTaxBracket(sourceCtx, taxYear)
+	if err == nil {
+		if validationErr := ValidateTaxBrackets(taxBrackets); validationErr != nil {
+			err = fmt.Errorf("%s tax brackets for %s: %w", source.Name, taxYear, validationErr)
+		}
+	}
+
+	s.record(ctx, source.Name, err)
+	if err != nil {
+		if ctx.Err() == nil && !isNotFound(err) {
+			s.logger.Printf("%s bracket source failed for %s: %v", source.Name, taxYear, err)
+		}
+		return nil, err
+	}
+
+	taxBrackets.Source = source.Name
+	s.logger.Printf("%s tax brackets served by the %s bracket source", taxYear, source.Name)
+	return taxBrackets, nil
+}
+
+// isHealthy reports whether the source is not being skipped after failing repeatedly.
+func (s *sourceChainTaxBracketService) isHealthy(name string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return !time.Now().Before(s.health[name].unhealthyUntil)
+}
+
+// record updates the health of a source with the outcome of a request. A year the source does not have and a request
+// cancelled by the caller do not count against the source.
+func (s *sourceChainTaxBracketService) record(ctx context.Context, name string, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	health := s.health[name]
+	if err == nil {
+		health.consecutiveFailures = 0
+		health.lastSuccess = time.Now()
+		health.unhealthyUntil = time.Time{}
+		return
+	}
+
+	if isNotFound(err) || !isUpstreamFailure(ctx, err) {
+		return
+	}
+
+	health.consecutiveFailures++
+	health.lastError = err.Error()
+	if health.consecutiveFailures >= s.options.FailureThreshold {
+		if time.Now().After(health.unhealthyUntil) {
+			s.logger.Printf("%s bracket source is unhealthy after %d consecutive failure(s), skipping it for %s", name, health.consecutiveFailures, s.options.RetryAfter)
+		}
+		health.unhealthyUntil = time.Now().Add(s.options.RetryAfter)
+	}
+}
+
+// isNotFound reports whether the error means the source does not have brackets for the year.
+func isNotFound(err error) bool {
+	if errors.Is(err, ErrTaxYearNotFound) {
+		return true
+	}
+
+	var fetchErr *BracketFetchError
+	return errors.As(err, &fetchErr) && fetchErr.StatusCode == http.StatusNotFound
+}
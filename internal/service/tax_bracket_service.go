@@ -1,6 +1,8 @@
 package service
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"github.com/goccy/go-json"
 	"github.com/siparisa/interview-test-server/internal/entity"
@@ -8,55 +10,206 @@ import (
 	"time"
 )
 
+const (
+	// FetchFailureNetwork means the request did not complete, including attempts that timed out.
+	FetchFailureNetwork = "network"
+	// FetchFailureStatus means the tax calculator API answered with an unexpected status code.
+	FetchFailureStatus = "status"
+	// FetchFailureDecode means the response body was not valid tax brackets.
+	FetchFailureDecode = "decode"
+	// FetchFailureInvalid means the response body was a malformed bracket table, see BracketValidationError.
+	FetchFailureInvalid = "invalid"
+	// FetchFailureAuth means the credentials for the tax calculator API could not be obtained.
+	FetchFailureAuth = "auth"
+	// FetchFailureRequest means the request could not be built, e.g. from a malformed tax calculator URL.
+	FetchFailureRequest = "request"
+)
+
+// errInvalidRequest is returned when the request to the tax calculator API cannot be built, which retrying won't fix.
+var errInvalidRequest = errors.New("invalid tax calculator request")
+
 // ITaxBracketService defines the interface for bracket-related calculations.
 type ITaxBracketService interface {
-	GetTaxBracket(taxYear string, maxRetries int, retryInterval time.Duration) (*entity.TaxBrackets, error)
+	GetTaxBracket(ctx context.Context, taxYear string) (*entity.TaxBrackets, error)
+}
+
+// BracketFetchError reports why the final attempt to retrieve the tax brackets for a year failed.
+type BracketFetchError struct {
+	TaxYear    string
+	Attempts   int
+	Reason     string
+	StatusCode int
+	Err        error
+
+	retryAfter time.Duration
+}
+
+func (e *BracketFetchError) Error() string {
+	msg := fmt.Sprintf("failed to get tax bracket for %s after %d attempt(s): %s failure", e.TaxYear, e.Attempts, e.Reason)
+	if e.StatusCode != 0 {
+		msg += fmt.Sprintf(" (status %d)", e.StatusCode)
+	}
+	if e.Err != nil {
+		msg += ": " + e.Err.Error()
+	}
+	return msg
+}
+
+func (e *BracketFetchError) Unwrap() error {
+	return e.Err
+}
+
+// Retryable reports whether the failure is transient: network errors and 429, 502, 503 and 504 responses.
+func (e *BracketFetchError) Retryable() bool {
+	switch e.Reason {
+	case FetchFailureNetwork:
+		return true
+	case FetchFailureStatus:
+		return isRetryableStatus(e.StatusCode)
+	}
+	return false
 }
 
 type taxBracketService struct {
 	taxCalculatorURL string
+	httpClient       *http.Client
+	attemptTimeout   time.Duration
+	overallDeadline  time.Duration
+	retryPolicy      RetryPolicy
+	authenticator    IRequestAuthenticator
 }
 
-// NewTaxBracketService creates a new instance of the taxBracketService.
-func NewTaxBracketService(taxCalculatorURL string) ITaxBracketService {
+// NewTaxBracketService creates a new instance of the taxBracketService. The HTTP client, timeouts, transport, retry
+// policy and request authentication are configured from the given options, falling back to the defaults for unset
+// values.
+func NewTaxBracketService(taxCalculatorURL string, options TaxBracketServiceOptions) (ITaxBracketService, error) {
+	httpClient, err := newHTTPClient(options)
+	if err != nil {
+		return nil, err
+	}
+
+	attemptTimeout := options.AttemptTimeout
+	if attemptTimeout <= 0 {
+		attemptTimeout = DefaultAttemptTimeout
+	}
+
+	overallDeadline := options.OverallDeadline
+	if overallDeadline <= 0 {
+		overallDeadline = DefaultOverallDeadline
+	}
+
 	return &taxBracketService{
 		taxCalculatorURL: taxCalculatorURL,
-	}
+		httpClient:       httpClient,
+		attemptTimeout:   attemptTimeout,
+		overallDeadline:  overallDeadline,
+		retryPolicy:      options.RetryPolicy.withDefaults(),
+		authenticator:    options.Authenticator,
+	}, nil
 }
 
-// GetTaxBracket retrieves the tax response for the given year from the tax calculator API.
-func (s *taxBracketService) GetTaxBracket(taxYear string, maxRetries int, retryInterval time.Duration) (*entity.TaxBrackets, error) {
+// GetTaxBracket retrieves the tax response for the given year from the tax calculator API. Only transient failures are
+// retried, waiting for the Retry-After of the response when given and the retry policy's backoff otherwise. The
+// requests and the waits are bound to the given context, so a cancelled or expired context stops immediately.
+// Each attempt is limited to the attempt timeout and all attempts together to the overall deadline.
+func (s *taxBracketService) GetTaxBracket(ctx context.Context, taxYear string) (*entity.TaxBrackets, error) {
 	url := fmt.Sprintf(s.taxCalculatorURL + taxYear)
 
-	for retry := 0; retry <= maxRetries; retry++ {
-		if retry > 0 {
-			// Wait for the specified retry interval before the next attempt
-			time.Sleep(retryInterval)
+	ctx, cancel := context.WithTimeout(ctx, s.overallDeadline)
+	defer cancel()
+
+	start := time.Now()
+	for attempt := 1; ; attempt++ {
+		taxBrackets, fetchErr := s.fetchTaxBrackets(ctx, url)
+		if fetchErr == nil {
+			taxBrackets.AsOf = time.Now()
+			taxBrackets.Source = TaxBracketSourceRemote
+			taxBrackets.EffectiveFrom = startOfTaxYear(taxYear)
+			identifyTaxBrackets(taxBrackets)
+
+			return taxBrackets, nil
 		}
 
-		resp, err := http.Get(url)
-		if err != nil {
-			continue
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
 		}
-		defer resp.Body.Close()
 
-		if resp.StatusCode != http.StatusOK {
-			continue
+		fetchErr.TaxYear = taxYear
+		fetchErr.Attempts = attempt
+		if !fetchErr.Retryable() || attempt >= s.retryPolicy.MaxAttempts {
+			return nil, fetchErr
+		}
+
+		// Wait for the upstream's Retry-After when given, the jittered backoff otherwise
+		wait := s.retryPolicy.Backoff(attempt)
+		if fetchErr.retryAfter > 0 {
+			wait = fetchErr.retryAfter
+		}
+		if time.Since(start)+wait > s.retryPolicy.MaxElapsedTime {
+			return nil, fetchErr
 		}
 
-		var taxBrackets entity.TaxBrackets
-		err = json.NewDecoder(resp.Body).Decode(&taxBrackets)
+		if err := sleepContext(ctx, wait); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// fetchTaxBrackets makes a single attempt to retrieve the tax brackets, limited to the attempt timeout. Rejected
+// credentials are refreshed and the request sent again once within the attempt.
+func (s *taxBracketService) fetchTaxBrackets(ctx context.Context, url string) (*entity.TaxBrackets, *BracketFetchError) {
+	ctx, cancel := context.WithTimeout(ctx, s.attemptTimeout)
+	defer cancel()
+
+	resp, err := doAuthenticated(ctx, s.httpClient, s.authenticator, func() (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 		if err != nil {
-			continue
+			return nil, fmt.Errorf("%w: %v", errInvalidRequest, err)
 		}
+		return req, nil
+	})
+	if errors.Is(err, errInvalidRequest) {
+		return nil, &BracketFetchError{Reason: FetchFailureRequest, Err: err}
+	}
+	if errors.Is(err, ErrUpstreamAuthentication) {
+		return nil, &BracketFetchError{Reason: FetchFailureAuth, Err: err}
+	}
+	if err != nil {
+		return nil, &BracketFetchError{Reason: FetchFailureNetwork, Err: err}
+	}
+	defer resp.Body.Close()
 
-		for i := range taxBrackets.TaxBrackets {
-			bandName := fmt.Sprintf("band%d", i+1)
-			taxBrackets.TaxBrackets[i].Band = bandName
+	if resp.StatusCode != http.StatusOK {
+		return nil, &BracketFetchError{
+			Reason:     FetchFailureStatus,
+			StatusCode: resp.StatusCode,
+			retryAfter: parseRetryAfter(resp.Header.Get("Retry-After")),
 		}
+	}
+
+	var taxBrackets entity.TaxBrackets
+	err = json.NewDecoder(resp.Body).Decode(&taxBrackets)
+	if err != nil {
+		return nil, &BracketFetchError{Reason: FetchFailureDecode, StatusCode: resp.StatusCode, Err: err}
+	}
 
-		return &taxBrackets, nil
+	err = ValidateTaxBrackets(&taxBrackets)
+	if err != nil {
+		return nil, &BracketFetchError{Reason: FetchFailureInvalid, StatusCode: resp.StatusCode, Err: err}
 	}
 
-	return nil, fmt.Errorf("failed to get tax bracket after %d retries", maxRetries)
+	return &taxBrackets, nil
+}
+
+// sleepContext waits for the given duration or until the context is done, whichever comes first.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
 }
@@ -0,0 +1,174 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"github.com/shopspring/decimal"
+	"github.com/siparisa/interview-test-server/internal/entity"
+)
+
+// ErrTaxYearRulesNotFound is returned when the credit, benefit or payroll rules of a tax year are not published, e.g.
+// for a year a bracket source serves before the rules are updated.
+var ErrTaxYearRulesNotFound = errors.New("rules not published for the given tax year")
+
+const (
+	donationCreditName = "charitableDonations"
+	medicalCreditName  = "medicalExpenses"
+)
+
+// ITaxCreditService defines the interface for non-refundable tax credit calculations.
+type ITaxCreditService interface {
+	CalculateTaxCredits(taxYear string, taxBrackets *entity.TaxBrackets, salary float64, inputs entity.TaxCreditInputs) (*entity.TaxCredits, error)
+	ApplyTaxCredits(totalTaxAmount float64, credits *entity.TaxCredits) (float64, error)
+}
+
+// federalCreditRules holds the federal credit rules published for a tax year.
+type federalCreditRules struct {
+	lowestRate             float64 // rate of the first federal bracket
+	donationLowTierAmount  float64 // donations up to this amount are credited at the lowest rate
+	donationHighRate       float64 // rate for donations above the low tier
+	donationTopRate        float64 // rate for donations made from income in the top bracket
+	donationIncomeLimit    float64 // share of net income that can be claimed as donations
+	medicalIncomeRate      float64 // share of net income used as the medical expense floor
+	medicalThresholdAmount float64 // fixed medical expense floor when lower than the income share
+}
+
+var federalCreditRulesByYear = map[string]federalCreditRules{
+	"2019": newFederalCreditRules(2352),
+	"2020": newFederalCreditRules(2397),
+	"2021": newFederalCreditRules(2421),
+	"2022": newFederalCreditRules(2479),
+}
+
+func newFederalCreditRules(medicalThresholdAmount float64) federalCreditRules {
+	return federalCreditRules{
+		lowestRate:             0.15,
+		donationLowTierAmount:  200,
+		donationHighRate:       0.29,
+		donationTopRate:        0.33,
+		donationIncomeLimit:    0.75,
+		medicalIncomeRate:      0.03,
+		medicalThresholdAmount: medicalThresholdAmount,
+	}
+}
+
+type taxCreditService struct{}
+
+// NewTaxCreditService creates a new instance of the taxCreditService.
+func NewTaxCreditService() ITaxCreditService {
+	return &taxCreditService{}
+}
+
+// CalculateTaxCredits calculates the donation and medical expense credits for the given salary and tax year. The rules
+// of the year are only needed when a credit is claimed.
+func (s *taxCreditService) CalculateTaxCredits(taxYear string, taxBrackets *entity.TaxBrackets, salary float64, inputs entity.TaxCreditInputs) (*entity.TaxCredits, error) {
+	if inputs.Donations < 0 || inputs.MedicalExpenses < 0 {
+		return nil, errors.New("tax credit amounts cannot be negative")
+	}
+
+	credits := &entity.TaxCredits{}
+	if inputs.Donations == 0 && inputs.MedicalExpenses == 0 {
+		return credits, nil
+	}
+
+	rules, ok := federalCreditRulesByYear[taxYear]
+	if !ok {
+		return nil, fmt.Errorf("tax credit rules for %s: %w", taxYear, ErrTaxYearRulesNotFound)
+	}
+
+	totalCreditAmount := decimal.NewFromFloat(0)
+
+	if inputs.Donations > 0 {
+		credit := calculateDonationCredit(rules, taxBrackets, salary, inputs.Donations)
+		credits.Credits = append(credits.Credits, credit)
+		totalCreditAmount = totalCreditAmount.Add(decimal.NewFromFloat(credit.Amount))
+	}
+
+	if inputs.MedicalExpenses > 0 {
+		credit := calculateMedicalCredit(rules, salary, inputs.MedicalExpenses)
+		credits.Credits = append(credits.Credits, credit)
+		totalCreditAmount = totalCreditAmount.Add(decimal.NewFromFloat(credit.Amount))
+	}
+
+	credits.TotalCreditAmount, _ = totalCreditAmount.Round(2).Float64()
+
+	return credits, nil
+}
+
+// ApplyTaxCredits reduces the total tax amount by the credits. Non-refundable credits never bring the tax below zero.
+func (s *taxCreditService) ApplyTaxCredits(totalTaxAmount float64, credits *entity.TaxCredits) (float64, error) {
+	if credits == nil {
+		return totalTaxAmount, nil
+	}
+
+	netTaxAmount := decimal.NewFromFloat(totalTaxAmount).Sub(decimal.NewFromFloat(credits.TotalCreditAmount))
+	if netTaxAmount.LessThan(decimal.NewFromFloat(0)) {
+		netTaxAmount = decimal.NewFromFloat(0)
+	}
+
+	roundedAmount, _ := netTaxAmount.Round(2).Float64()
+	return roundedAmount, nil
+}
+
+// calculateDonationCredit applies the two-tier donation credit. The first tier is credited at the lowest rate, the
+// remainder at the high rate, except for the part made from income in the top bracket which is credited at the top rate.
+func calculateDonationCredit(rules federalCreditRules, taxBrackets *entity.TaxBrackets, salary, donations float64) entity.TaxCredit {
+	decimalSalary := decimal.NewFromFloat(salary)
+	eligibleDonations := decimal.Min(decimal.NewFromFloat(donations), decimalSalary.Mul(decimal.NewFromFloat(rules.donationIncomeLimit)))
+
+	lowTier := decimal.Min(eligibleDonations, decimal.NewFromFloat(rules.donationLowTierAmount))
+	creditAmount := lowTier.Mul(decimal.NewFromFloat(rules.lowestRate))
+
+	highTier := eligibleDonations.Sub(lowTier)
+	if highTier.GreaterThan(decimal.NewFromFloat(0)) {
+		topTier := decimal.NewFromFloat(0)
+		if topBracketMin, ok := getTopBracketMin(taxBrackets); ok && decimalSalary.GreaterThan(topBracketMin) {
+			topTier = decimal.Min(highTier, decimalSalary.Sub(topBracketMin))
+		}
+
+		creditAmount = creditAmount.
+			Add(topTier.Mul(decimal.NewFromFloat(rules.donationTopRate))).
+			Add(highTier.Sub(topTier).Mul(decimal.NewFromFloat(rules.donationHighRate)))
+	}
+
+	roundedEligible, _ := eligibleDonations.Round(2).Float64()
+	roundedAmount, _ := creditAmount.Round(2).Float64()
+
+	return entity.TaxCredit{
+		Name:           donationCreditName,
+		EligibleAmount: roundedEligible,
+		Amount:         roundedAmount,
+	}
+}
+
+// calculateMedicalCredit credits the medical expenses above the lesser of the income share and the fixed threshold.
+func calculateMedicalCredit(rules federalCreditRules, salary, medicalExpenses float64) entity.TaxCredit {
+	floor := decimal.Min(
+		decimal.NewFromFloat(salary).Mul(decimal.NewFromFloat(rules.medicalIncomeRate)),
+		decimal.NewFromFloat(rules.medicalThresholdAmount),
+	)
+
+	eligibleExpenses := decimal.NewFromFloat(medicalExpenses).Sub(floor)
+	if eligibleExpenses.LessThan(decimal.NewFromFloat(0)) {
+		eligibleExpenses = decimal.NewFromFloat(0)
+	}
+
+	roundedEligible, _ := eligibleExpenses.Round(2).Float64()
+	roundedAmount, _ := eligibleExpenses.Mul(decimal.NewFromFloat(rules.lowestRate)).Round(2).Float64()
+
+	return entity.TaxCredit{
+		Name:           medicalCreditName,
+		EligibleAmount: roundedEligible,
+		Amount:         roundedAmount,
+	}
+}
+
+// getTopBracketMin returns the minimum value of the open-ended top bracket.
+func getTopBracketMin(taxBrackets *entity.TaxBrackets) (decimal.Decimal, bool) {
+	if taxBrackets == nil || len(taxBrackets.TaxBrackets) == 0 {
+		return decimal.Decimal{}, false
+	}
+
+	topBracket := taxBrackets.TaxBrackets[len(taxBrackets.TaxBrackets)-1]
+	return decimal.NewFromFloat(topBracket.Min), true
+}
@@ -0,0 +1,158 @@
+package service
+
+import (
+	"context"
+	"github.com/siparisa/interview-test-server/internal/entity"
+	"log"
+	"sort"
+	"sync"
+	"time"
+)
+
+// DefaultTaxYearRefreshInterval is how often the tax years of the bracket sources are refreshed when no interval is
+// configured.
+const DefaultTaxYearRefreshInterval = 10 * time.Minute
+
+// ITaxYearSource is a bracket source that can list the tax years it has brackets for.
+type ITaxYearSource interface {
+	GetTaxYears(ctx context.Context) ([]string, error)
+}
+
+// TaxYearSource is a named ITaxYearSource.
+type TaxYearSource struct {
+	Name   string
+	Source ITaxYearSource
+}
+
+// TaxYearOptions configures how often the tax years are refreshed.
+type TaxYearOptions struct {
+	RefreshInterval time.Duration
+}
+
+// ITaxYearService defines the interface for the tax years supported by the bracket sources.
+type ITaxYearService interface {
+	GetTaxYears() []entity.TaxYear
+	GetSupportedTaxYears() []string
+	Close()
+}
+
+// sourceTaxYears holds the last tax years listed by a source.
+type sourceTaxYears struct {
+	taxYears    []string
+	refreshedAt time.Time
+}
+
+type taxYearService struct {
+	sources []TaxYearSource
+	options TaxYearOptions
+	logger  *log.Logger
+
+	mu            sync.RWMutex
+	yearsBySource map[string]sourceTaxYears
+
+	stop chan struct{}
+	once sync.Once
+}
+
+// NewTaxYearService creates a new instance of the taxYearService over the given sources, in the order they take
+// precedence. The tax years are listed once before returning and then refreshed every refresh interval until Close is
+// called.
+func NewTaxYearService(sources []TaxYearSource, options TaxYearOptions, logger *log.Logger) ITaxYearService {
+	if options.RefreshInterval <= 0 {
+		options.RefreshInterval = DefaultTaxYearRefreshInterval
+	}
+
+	s := &taxYearService{
+		sources:       sources,
+		options:       options,
+		logger:        logger,
+		yearsBySource: make(map[string]sourceTaxYears),
+		stop:          make(chan struct{}),
+	}
+	s.refresh()
+
+	go s.watch()
+
+	return s
+}
+
+// GetTaxYears lists each supported tax year with the source its brackets come from, the first source listing the
+// year, and when that source was last refreshed.
+func (s *taxYearService) GetTaxYears() []entity.TaxYear {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	seen := make(map[string]bool)
+	var taxYears []entity.TaxYear
+	for _, source := range s.sources {
+		years := s.yearsBySource[source.Name]
+		for _, year := range years.taxYears {
+			if seen[year] {
+				continue
+			}
+
+			seen[year] = true
+			taxYears = append(taxYears, entity.TaxYear{
+				Year:        year,
+				Source:      source.Name,
+				RefreshedAt: years.refreshedAt,
+			})
+		}
+	}
+
+	sort.Slice(taxYears, func(i, j int) bool {
+		return taxYears[i].Year < taxYears[j].Year
+	})
+
+	return taxYears
+}
+
+// GetSupportedTaxYears lists the supported tax years.
+func (s *taxYearService) GetSupportedTaxYears() []string {
+	var years []string
+	for _, taxYear := range s.GetTaxYears() {
+		years = append(years, taxYear.Year)
+	}
+
+	return years
+}
+
+// Close stops refreshing the tax years.
+func (s *taxYearService) Close() {
+	s.once.Do(func() {
+		close(s.stop)
+	})
+}
+
+// watch refreshes the tax years every refresh interval until the service is closed.
+func (s *taxYearService) watch() {
+	ticker := time.NewTicker(s.options.RefreshInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-ticker.C:
+			s.refresh()
+		}
+	}
+}
+
+// refresh lists the tax years of every source. A source that fails keeps the tax years it listed last.
+func (s *taxYearService) refresh() {
+	for _, source := range s.sources {
+		taxYears, err := source.Source.GetTaxYears(context.Background())
+		if err != nil {
+			s.logger.Printf("failed to list the tax years of the %s bracket source: %v", source.Name, err)
+			continue
+		}
+
+		s.mu.Lock()
+		s.yearsBySource[source.Name] = sourceTaxYears{
+			taxYears:    taxYears,
+			refreshedAt: time.Now(),
+		}
+		s.mu.Unlock()
+	}
+}
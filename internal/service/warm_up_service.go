@@ -0,0 +1,184 @@
+package service
+
+import (
+	"context"
+	"github.com/siparisa/interview-test-server/internal/entity"
+	"log"
+	"sort"
+	"sync"
+	"time"
+)
+
+// The states of the warm-up.
+const (
+	WarmUpInProgress = "warming-up"
+	WarmUpComplete   = "complete"
+	WarmUpTimedOut   = "timed-out"
+)
+
+const (
+	// DefaultWarmUpTimeout limits the warm-up when no timeout is configured.
+	DefaultWarmUpTimeout = 30 * time.Second
+	// DefaultWarmUpRetryInterval is the wait before the years that failed are fetched again.
+	DefaultWarmUpRetryInterval = time.Second
+)
+
+// WarmUpOptions configures how long the warm-up may keep the service not ready.
+type WarmUpOptions struct {
+	Timeout       time.Duration
+	RetryInterval time.Duration
+}
+
+// IWarmUpService defines the interface for the warm-up of the brackets at startup.
+type IWarmUpService interface {
+	GetWarmUpStatus() entity.WarmUpStatus
+}
+
+type warmUpService struct {
+	taxBracketService ITaxBracketService
+	taxYearService    ITaxYearService
+	options           WarmUpOptions
+	logger            *log.Logger
+
+	mu     sync.Mutex
+	status entity.WarmUpStatus
+}
+
+// NewWarmUpService creates a new instance of the warmUpService and starts pre-fetching the brackets of every supported
+// tax year in the background through the given ITaxBracketService, filling the bracket caches along the way. A year no
+// source has is skipped. The years that fail otherwise are fetched again every retry interval until they all succeed
+// or the timeout passes.
+func NewWarmUpService(taxBracketService ITaxBracketService, taxYearService ITaxYearService, options WarmUpOptions, logger *log.Logger) IWarmUpService {
+	if options.Timeout <= 0 {
+		options.Timeout = DefaultWarmUpTimeout
+	}
+	if options.RetryInterval <= 0 {
+		options.RetryInterval = DefaultWarmUpRetryInterval
+	}
+
+	s := &warmUpService{
+		taxBracketService: taxBracketService,
+		taxYearService:    taxYearService,
+		options:           options,
+		logger:            logger,
+		status: entity.WarmUpStatus{
+			State:          WarmUpInProgress,
+			StartedAt:      time.Now(),
+			WarmedTaxYears: []string{},
+		},
+	}
+
+	go s.warmUp()
+
+	return s
+}
+
+// GetWarmUpStatus reports the progress of the warm-up.
+func (s *warmUpService) GetWarmUpStatus() entity.WarmUpStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	status := s.status
+	status.WarmedTaxYears = append([]string{}, s.status.WarmedTaxYears...)
+	status.SkippedTaxYears = append([]string(nil), s.status.SkippedTaxYears...)
+	if s.status.FailedTaxYears != nil {
+		status.FailedTaxYears = make(map[string]string)
+		for taxYear, err := range s.status.FailedTaxYears {
+			status.FailedTaxYears[taxYear] = err
+		}
+	}
+
+	return status
+}
+
+// warmUp fetches the brackets of the supported tax years until every year succeeded or the timeout passed.
+func (s *warmUpService) warmUp() {
+	ctx, cancel := context.WithTimeout(context.Background(), s.options.Timeout)
+	defer cancel()
+
+	pending := s.taxYearService.GetSupportedTaxYears()
+	s.logger.Printf("Warming up the brackets of %d tax year(s)", len(pending))
+
+	for {
+		pending = s.fetch(ctx, pending)
+		if len(pending) == 0 {
+			s.complete(WarmUpComplete)
+			s.logger.Printf("Warm-up complete in %s", time.Since(s.status.StartedAt).Round(time.Millisecond))
+			return
+		}
+
+		if err := sleepContext(ctx, s.options.RetryInterval); err != nil {
+			s.complete(WarmUpTimedOut)
+			s.logger.Printf("Warm-up timed out after %s, the brackets of %v are fetched on first use", s.options.Timeout, pending)
+			return
+		}
+	}
+}
+
+// fetch retrieves the brackets of the tax years concurrently, recording the outcome of each, and returns the years
+// that failed.
+func (s *warmUpService) fetch(ctx context.Context, taxYears []string) []string {
+	var wg sync.WaitGroup
+	for _, taxYear := range taxYears {
+		wg.Add(1)
+		go func(taxYear string) {
+			defer wg.Done()
+
+			_, err := s.taxBracketService.GetTaxBracket(ctx, taxYear)
+			s.record(taxYear, err)
+		}(taxYear)
+	}
+	wg.Wait()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var failed []string
+	for taxYear := range s.status.FailedTaxYears {
+		failed = append(failed, taxYear)
+	}
+	sort.Strings(failed)
+
+	return failed
+}
+
+// record updates the warmed, skipped and failed tax years with the outcome of fetching a year. A year no source has
+// is skipped rather than failed, fetching it again would not help.
+func (s *warmUpService) record(taxYear string, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err != nil && !isNotFound(err) {
+		if s.status.FailedTaxYears == nil {
+			s.status.FailedTaxYears = make(map[string]string)
+		}
+		s.status.FailedTaxYears[taxYear] = err.Error()
+		return
+	}
+
+	delete(s.status.FailedTaxYears, taxYear)
+	if len(s.status.FailedTaxYears) == 0 {
+		s.status.FailedTaxYears = nil
+	}
+
+	if err != nil {
+		s.logger.Printf("Skipping the warm-up of %s: %v", taxYear, err)
+		s.status.SkippedTaxYears = append(s.status.SkippedTaxYears, taxYear)
+		sort.Strings(s.status.SkippedTaxYears)
+		return
+	}
+
+	s.status.WarmedTaxYears = append(s.status.WarmedTaxYears, taxYear)
+	sort.Strings(s.status.WarmedTaxYears)
+}
+
+// complete ends the warm-up in the given state, marking the service ready.
+func (s *warmUpService) complete(state string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	completedAt := time.Now()
+	s.status.State = state
+	s.status.Ready = true
+	s.status.CompletedAt = &completedAt
+}
@@ -0,0 +1,115 @@
+package tests
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/siparisa/interview-test-server/internal/entity"
+	"github.com/siparisa/interview-test-server/internal/service"
+)
+
+func TestEstimateBenefits(t *testing.T) {
+	benefitService := service.NewBenefitService()
+
+	t.Run("CoupleWithChildUnder6", func(t *testing.T) {
+		estimate, err := benefitService.EstimateBenefits("2021", entity.BenefitInputs{
+			FamilyNetIncome: 60000,
+			MaritalStatus:   service.MaritalStatusMarried,
+			ChildrenUnder6:  1,
+		})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		// 6997 less 7% of the income above 32797
+		expectedChildBenefit := 5092.79
+		if estimate.CanadaChildBenefit != expectedChildBenefit {
+			t.Errorf("Expected Canada Child Benefit %f, but got %f", expectedChildBenefit, estimate.CanadaChildBenefit)
+		}
+		if estimate.GSTHSTCredit != 0 {
+			t.Errorf("Expected GST/HST credit to be fully phased out, but got %f", estimate.GSTHSTCredit)
+		}
+		if estimate.BenefitYear != "July 2022 - June 2023" {
+			t.Errorf("Expected benefit year July 2022 - June 2023, but got %s", estimate.BenefitYear)
+		}
+	})
+
+	t.Run("SingleWithoutChildren", func(t *testing.T) {
+		estimate, err := benefitService.EstimateBenefits("2021", entity.BenefitInputs{
+			FamilyNetIncome: 20000,
+			MaritalStatus:   service.MaritalStatusSingle,
+		})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		// Adult amount plus the fully phased-in single supplement
+		expectedGSTCredit := 467.0
+		if estimate.GSTHSTCredit != expectedGSTCredit {
+			t.Errorf("Expected GST/HST credit %f, but got %f", expectedGSTCredit, estimate.GSTHSTCredit)
+		}
+		if estimate.CanadaChildBenefit != 0 {
+			t.Errorf("Expected no Canada Child Benefit, but got %f", estimate.CanadaChildBenefit)
+		}
+	})
+
+	t.Run("PublishedGSTCreditAmounts", func(t *testing.T) {
+		// The maximum credits published for each benefit year, and the credit of a couple with two children reduced by
+		// 5% of the 1000 of family net income above the phase-out threshold
+		for _, tc := range []struct {
+			taxYear       string
+			single        float64
+			coupleTwoKids float64
+			threshold     float64
+		}{
+			{taxYear: "2019", single: 451, coupleTwoKids: 902, threshold: 38507},
+			{taxYear: "2020", single: 456, coupleTwoKids: 912, threshold: 38892},
+			{taxYear: "2021", single: 467, coupleTwoKids: 934, threshold: 39826},
+			{taxYear: "2022", single: 496, coupleTwoKids: 992, threshold: 42335},
+		} {
+			single, err := benefitService.EstimateBenefits(tc.taxYear, entity.BenefitInputs{
+				FamilyNetIncome: 25000,
+				MaritalStatus:   service.MaritalStatusSingle,
+			})
+			if err != nil {
+				t.Fatalf("Unexpected error for %s: %v", tc.taxYear, err)
+			}
+			if single.GSTHSTCredit != tc.single {
+				t.Errorf("Expected the %s single credit %f, but got %f", tc.taxYear, tc.single, single.GSTHSTCredit)
+			}
+
+			couple, err := benefitService.EstimateBenefits(tc.taxYear, entity.BenefitInputs{
+				FamilyNetIncome: 20000,
+				MaritalStatus:   service.MaritalStatusMarried,
+				Children6To17:   2,
+			})
+			if err != nil {
+				t.Fatalf("Unexpected error for %s: %v", tc.taxYear, err)
+			}
+			if couple.GSTHSTCredit != tc.coupleTwoKids {
+				t.Errorf("Expected the %s couple credit %f, but got %f", tc.taxYear, tc.coupleTwoKids, couple.GSTHSTCredit)
+			}
+
+			reduced, _ := benefitService.EstimateBenefits(tc.taxYear, entity.BenefitInputs{
+				FamilyNetIncome: tc.threshold + 1000,
+				MaritalStatus:   service.MaritalStatusMarried,
+				Children6To17:   2,
+			})
+			if reduced.GSTHSTCredit != tc.coupleTwoKids-50 {
+				t.Errorf("Expected the %s credit above the threshold %f, but got %f", tc.taxYear, tc.coupleTwoKids-50, reduced.GSTHSTCredit)
+			}
+		}
+	})
+	t.Run("BenefitTaxYears", func(t *testing.T) {
+		taxYears := benefitService.GetBenefitTaxYears()
+		if strings.Join(taxYears, ",") != "2019,2020,2021,2022" {
+			t.Errorf("Expected the benefit tables of 2019 to 2022, but got %v", taxYears)
+		}
+
+		_, err := benefitService.EstimateBenefits("2023", entity.BenefitInputs{MaritalStatus: service.MaritalStatusSingle})
+		if !errors.Is(err, service.ErrTaxYearRulesNotFound) {
+			t.Errorf("Expected ErrTaxYearRulesNotFound for 2023, but got %v", err)
+		}
+	})
+}
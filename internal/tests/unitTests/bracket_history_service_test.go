@@ -0,0 +1,200 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/siparisa/interview-test-server/internal/entity"
+	"github.com/siparisa/interview-test-server/internal/service"
+)
+
+// stubTaxBracketService serves a single open-ended bracket at the given rate, effective from the start of 2022 unless
+// another effective date is set.
+type stubTaxBracketService struct {
+	mu            sync.Mutex
+	rate          float64
+	effectiveFrom time.Time
+}
+
+func (s *stubTaxBracketService) GetTaxBracket(ctx context.Context, taxYear string) (*entity.TaxBrackets, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	effectiveFrom := s.effectiveFrom
+	if effectiveFrom.IsZero() {
+		effectiveFrom = time.Date(2022, time.January, 1, 0, 0, 0, 0, time.UTC)
+	}
+
+	return &entity.TaxBrackets{
+		TaxBrackets:   []entity.TaxBracket{{Band: "0+", Rate: s.rate}},
+		Source:        service.TaxBracketSourceRemote,
+		EffectiveFrom: effectiveFrom,
+	}, nil
+}
+
+func (s *stubTaxBracketService) setRate(rate float64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.rate = rate
+}
+
+func (s *stubTaxBracketService) setEffectiveFrom(effectiveFrom time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.effectiveFrom = effectiveFrom
+}
+
+func TestBracketHistoryService(t *testing.T) {
+	historyFile := filepath.Join(t.TempDir(), "history.jsonl")
+	upstream := &stubTaxBracketService{rate: 0.15}
+	newService := func() service.IBracketHistoryService {
+		reports, err := service.NewBracketReportService(service.NewBracketImpactService(service.NewTaxService(), service.BracketImpactOptions{}), service.BracketReportOptions{File: filepath.Join(filepath.Dir(historyFile), "history.impact.jsonl")}, log.New(io.Discard, "", 0))
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		history, err := service.NewBracketHistoryService(service.NewJurisdictionTaxBracketService(upstream, nil), reports, service.BracketHistoryOptions{File: historyFile}, log.New(io.Discard, "", 0))
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		return history
+	}
+	history := newService()
+	ctx := context.Background()
+
+	t.Run("NumbersDistinctVersions", func(t *testing.T) {
+		first, _ := history.GetTaxBracket(ctx, "2022")
+		again, _ := history.GetTaxBracket(ctx, "2022")
+
+		upstream.setRate(0.16)
+		amended, _ := history.GetTaxBracket(ctx, "2022")
+
+		if first.Version != 1 || again.Version != 1 || amended.Version != 2 {
+			t.Errorf("Expected versions 1, 1 and 2, but got %d, %d and %d", first.Version, again.Version, amended.Version)
+		}
+		if versions := history.GetTaxBracketHistory(service.FederalJurisdiction, "2022"); len(versions) != 2 {
+			t.Errorf("Expected 2 recorded versions, but got %+v", versions)
+		}
+
+		reports := history.GetBracketImpactReports(service.FederalJurisdiction, "2022")
+		if len(reports) != 1 || reports[0].Before != "version 1 from remote" || len(reports[0].BandChanges) != 1 {
+			t.Errorf("Expected an impact report of version 2 replacing version 1, but got %+v", reports)
+		}
+	})
+
+	t.Run("ReproducesRecordedVersion", func(t *testing.T) {
+		taxBrackets, err := history.GetTaxBracket(service.WithBracketVersion(ctx, 1), "2022")
+		if err != nil || taxBrackets.TaxBrackets[0].Rate != 0.15 || taxBrackets.Version != 1 {
+			t.Errorf("Expected version 1 at 15%%, but got %+v, %v", taxBrackets, err)
+		}
+	})
+
+	t.Run("ComparesVersions", func(t *testing.T) {
+		report, err := history.CompareTaxBracketVersions(service.FederalJurisdiction, "2022", 1, 2, []float64{10000})
+		if err != nil || report.Salaries[0].Difference != 100 {
+			t.Errorf("Expected a tax increase of 100, but got %+v, %v", report, err)
+		}
+	})
+
+	t.Run("AnswersAsOfFromHistory", func(t *testing.T) {
+		// A change upstream is not what was in effect on a date the history already covers
+		upstream.setRate(0.17)
+		defer upstream.setRate(0.16)
+
+		taxBrackets, err := history.GetTaxBracket(service.WithAsOf(ctx, time.Now().Add(time.Minute)), "2022")
+		if err != nil || taxBrackets.Version != 2 || taxBrackets.TaxBrackets[0].Rate != 0.16 {
+			t.Errorf("Expected the recorded version 2 at 16%%, but got %+v, %v", taxBrackets, err)
+		}
+		if versions := history.GetTaxBracketHistory(service.FederalJurisdiction, "2022"); len(versions) != 2 {
+			t.Errorf("Expected no version to be recorded, but got %+v", versions)
+		}
+	})
+
+	t.Run("RecordsProvincialBrackets", func(t *testing.T) {
+		taxBrackets, err := history.GetJurisdictionTaxBracket(ctx, "on", "2022")
+		if err != nil || taxBrackets.Version != 1 || taxBrackets.Source != service.TaxBracketSourceEmbedded {
+			t.Fatalf("Expected version 1 of the built-in Ontario brackets, but got %+v, %v", taxBrackets, err)
+		}
+
+		versions := history.GetTaxBracketHistory("ON", "2022")
+		if len(versions) != 1 || versions[0].Jurisdiction != "ON" {
+			t.Errorf("Expected 1 recorded Ontario version, but got %+v", versions)
+		}
+		if federal := history.GetTaxBracketHistory(service.FederalJurisdiction, "2022"); len(federal) != 2 {
+			t.Errorf("Expected the federal versions to be kept apart, but got %+v", federal)
+		}
+	})
+
+	t.Run("UnknownVersion", func(t *testing.T) {
+		_, err := history.GetTaxBracket(service.WithBracketVersion(ctx, 3), "2022")
+		if !errors.Is(err, service.ErrTaxBracketVersionNotFound) {
+			t.Errorf("Expected ErrTaxBracketVersionNotFound, but got %v", err)
+		}
+	})
+
+	t.Run("SurvivesRestart", func(t *testing.T) {
+		restarted := newService()
+
+		taxBrackets, err := restarted.GetTaxBracket(service.WithBracketVersion(ctx, 1), "2022")
+		if err != nil || taxBrackets.TaxBrackets[0].Rate != 0.15 {
+			t.Errorf("Expected the recorded version 1, but got %+v, %v", taxBrackets, err)
+		}
+
+		current, _ := restarted.GetTaxBracket(ctx, "2022")
+		if current.Version != 2 {
+			t.Errorf("Expected the current brackets to stay version 2, but got %d", current.Version)
+		}
+
+		ontario, err := restarted.GetJurisdictionTaxBracket(service.WithBracketVersion(ctx, 1), "ON", "2022")
+		if err != nil || ontario.Source != service.TaxBracketSourceEmbedded {
+			t.Errorf("Expected the recorded Ontario version 1, but got %+v, %v", ontario, err)
+		}
+
+		if reports := restarted.GetBracketImpactReports(service.FederalJurisdiction, "2022"); len(reports) != 1 || reports[0].After != "version 2 from remote" {
+			t.Errorf("Expected the impact report to be restored, but got %+v", reports)
+		}
+	})
+}
+
+func TestBracketHistoryServiceReportsAgainstEarlierVersion(t *testing.T) {
+	upstream := &stubTaxBracketService{rate: 0.16, effectiveFrom: time.Date(2022, time.July, 1, 0, 0, 0, 0, time.UTC)}
+	reports, err := service.NewBracketReportService(service.NewBracketImpactService(service.NewTaxService(), service.BracketImpactOptions{}), service.BracketReportOptions{}, log.New(io.Discard, "", 0))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	history, err := service.NewBracketHistoryService(service.NewJurisdictionTaxBracketService(upstream, nil), reports, service.BracketHistoryOptions{}, log.New(io.Discard, "", 0))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	ctx := context.Background()
+
+	if _, err := history.GetTaxBracket(ctx, "2022"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// The January brackets backfilled after the July amendment replace neither it nor anything before
+	upstream.setRate(0.15)
+	upstream.setEffectiveFrom(time.Date(2022, time.January, 1, 0, 0, 0, 0, time.UTC))
+	if backfilled, _ := history.GetTaxBracket(ctx, "2022"); backfilled.Version != 2 {
+		t.Fatalf("Expected the January brackets to be recorded as version 2, but got %+v", backfilled)
+	}
+	if impact := history.GetBracketImpactReports(service.FederalJurisdiction, "2022"); len(impact) != 0 {
+		t.Errorf("Expected no impact report for the backfilled brackets, but got %+v", impact)
+	}
+
+	// A later amendment is reported against the July brackets it follows, not the January ones recorded last
+	upstream.setRate(0.17)
+	upstream.setEffectiveFrom(time.Date(2022, time.October, 1, 0, 0, 0, 0, time.UTC))
+	history.GetTaxBracket(ctx, "2022")
+	impact := history.GetBracketImpactReports(service.FederalJurisdiction, "2022")
+	if len(impact) != 1 || impact[0].Before != "version 1 from remote" || impact[0].After != "version 3 from remote" {
+		t.Errorf("Expected an impact report of version 3 replacing version 1, but got %+v", impact)
+	}
+}
@@ -0,0 +1,48 @@
+package tests
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/siparisa/interview-test-server/internal/entity"
+	"github.com/siparisa/interview-test-server/internal/service"
+)
+
+func TestCompareTaxBrackets(t *testing.T) {
+	impactService := service.NewBracketImpactService(service.NewTaxService(), service.BracketImpactOptions{ReferenceSalaries: []float64{40000, 60000}})
+
+	before := &entity.TaxBrackets{TaxBrackets: []entity.TaxBracket{
+		{Band: "0-50000", Max: 50000, Rate: 0.15},
+		{Band: "50000+", Min: 50000, Rate: 0.2},
+	}}
+	after := &entity.TaxBrackets{TaxBrackets: []entity.TaxBracket{
+		{Band: "0-50000", Max: 50000, Rate: 0.14},
+		{Band: "50000-100000", Min: 50000, Max: 100000, Rate: 0.2},
+		{Band: "100000+", Min: 100000, Rate: 0.3},
+	}}
+
+	report, err := impactService.CompareTaxBrackets("2022", before, after, nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	var changes []string
+	for _, change := range report.BandChanges {
+		changes = append(changes, change.Change+" "+change.Band)
+	}
+	expectedChanges := []string{"changed 0-50000", "changed 50000-100000", "added 100000+"}
+	if !reflect.DeepEqual(changes, expectedChanges) {
+		t.Errorf("Expected band changes %v, but got %v", expectedChanges, changes)
+	}
+	if fields := report.BandChanges[1].Fields; !reflect.DeepEqual(fields, []string{"band", "max"}) {
+		t.Errorf("Expected the renamed top band to change its band and max, but got %v", fields)
+	}
+
+	expectedSalaries := []entity.SalaryImpact{
+		{Salary: 40000, BeforeTaxAmount: 6000, AfterTaxAmount: 5600, Difference: -400},
+		{Salary: 60000, BeforeTaxAmount: 9500, AfterTaxAmount: 9000, Difference: -500},
+	}
+	if !reflect.DeepEqual(report.Salaries, expectedSalaries) {
+		t.Errorf("Expected salary impacts %+v, but got %+v", expectedSalaries, report.Salaries)
+	}
+}
@@ -0,0 +1,166 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/siparisa/interview-test-server/internal/entity"
+	"github.com/siparisa/interview-test-server/internal/service"
+)
+
+func TestBracketOverrideService(t *testing.T) {
+	auditFile := filepath.Join(t.TempDir(), "audit.jsonl")
+	newService := func() service.IBracketOverrideService {
+		overrides, err := service.NewBracketOverrideService(service.NewJurisdictionTaxBracketService(&mockTaxBracketService{}, nil), service.NewBracketImpactService(service.NewTaxService(), service.BracketImpactOptions{}), service.BracketOverrideOptions{AuditFile: auditFile}, log.New(io.Discard, "", 0))
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		return overrides
+	}
+	overrides := newService()
+	ctx := context.Background()
+	flatTax := []entity.TaxBracket{{Min: 0, Rate: 0.2}}
+
+	t.Run("RejectsInvalidTable", func(t *testing.T) {
+		_, err := overrides.PublishTaxBracketOverride(service.FederalJurisdiction, "2019", time.Time{}, []entity.TaxBracket{{Min: 100, Rate: 2}}, "alice", "")
+		var validationErr *service.BracketValidationError
+		if !errors.As(err, &validationErr) {
+			t.Errorf("Expected a *BracketValidationError, but got %v", err)
+		}
+	})
+
+	t.Run("RejectsUnsupportedJurisdiction", func(t *testing.T) {
+		_, err := overrides.PublishTaxBracketOverride("bc", "2019", time.Time{}, flatTax, "alice", "")
+		if !errors.Is(err, service.ErrUnsupportedOverrideJurisdiction) {
+			t.Errorf("Expected ErrUnsupportedOverrideJurisdiction, but got %v", err)
+		}
+	})
+
+	t.Run("RejectsDateOutsideTaxYear", func(t *testing.T) {
+		_, err := overrides.PublishTaxBracketOverride(service.FederalJurisdiction, "2019", time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC), flatTax, "alice", "")
+		if !errors.Is(err, service.ErrInvalidOverrideEffectiveDate) {
+			t.Errorf("Expected ErrInvalidOverrideEffectiveDate, but got %v", err)
+		}
+	})
+
+	t.Run("StagedTableIsNotServed", func(t *testing.T) {
+		staged, err := overrides.PublishTaxBracketOverride(service.FederalJurisdiction, "2019", time.Time{}, flatTax, "alice", "upstream rate typo")
+		if err != nil || staged.Revision != 1 || staged.TaxBrackets[0].Band != "0+" {
+			t.Fatalf("Expected revision 1 to be staged, but got %+v, %v", staged, err)
+		}
+
+		taxBrackets, _ := overrides.GetTaxBracket(ctx, "2019")
+		if taxBrackets.Source == service.TaxBracketSourceOverride {
+			t.Errorf("Expected the staged table not to be served before activation")
+		}
+	})
+
+	t.Run("PreviewsStagedTable", func(t *testing.T) {
+		preview, err := overrides.PreviewTaxBracketOverride(ctx, service.FederalJurisdiction, "2019", []float64{40000})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		salary := preview.Salaries[0]
+		if salary.BeforeTaxAmount != 6000 || salary.AfterTaxAmount != 8000 || salary.Difference != 2000 {
+			t.Errorf("Expected a tax of 6000 rising to 8000, but got %+v", salary)
+		}
+	})
+
+	t.Run("ActivatesStagedTable", func(t *testing.T) {
+		if _, err := overrides.ActivateTaxBracketOverride(service.FederalJurisdiction, "2019", "bob"); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		taxBrackets, _ := overrides.GetTaxBracket(ctx, "2019")
+		if taxBrackets.Source != service.TaxBracketSourceOverride || taxBrackets.TaxBrackets[0].Rate != 0.2 {
+			t.Errorf("Expected the override to be served, but got %+v", taxBrackets)
+		}
+
+		if _, err := overrides.ActivateTaxBracketOverride(service.FederalJurisdiction, "2019", "bob"); !errors.Is(err, service.ErrBracketOverrideNotFound) {
+			t.Errorf("Expected ErrBracketOverrideNotFound without a staged table, but got %v", err)
+		}
+	})
+
+	t.Run("RestoresFromAuditLog", func(t *testing.T) {
+		restarted := newService()
+
+		taxBrackets, _ := restarted.GetTaxBracket(ctx, "2019")
+		if taxBrackets.Source != service.TaxBracketSourceOverride {
+			t.Errorf("Expected the override to be restored, but got %+v", taxBrackets)
+		}
+
+		auditLog := restarted.GetBracketAuditLog()
+		if len(auditLog) != 2 || auditLog[0].Actor != "alice" || auditLog[1].Action != service.BracketAuditActivate {
+			t.Errorf("Expected the publish and activation to be audited, but got %+v", auditLog)
+		}
+	})
+
+	t.Run("RollsBack", func(t *testing.T) {
+		status, err := overrides.RollbackTaxBracketOverride(service.FederalJurisdiction, "2019", "alice")
+		if err != nil || status.Active != nil {
+			t.Fatalf("Expected no active override left, but got %+v, %v", status, err)
+		}
+
+		taxBrackets, _ := overrides.GetTaxBracket(ctx, "2019")
+		if taxBrackets.Source == service.TaxBracketSourceOverride || len(taxBrackets.TaxBrackets) != 5 {
+			t.Errorf("Expected the wrapped brackets to be served again, but got %+v", taxBrackets)
+		}
+
+		if _, err := overrides.RollbackTaxBracketOverride(service.FederalJurisdiction, "2019", "alice"); !errors.Is(err, service.ErrBracketOverrideNotFound) {
+			t.Errorf("Expected ErrBracketOverrideNotFound without an active override, but got %v", err)
+		}
+	})
+
+	t.Run("OverridesProvincialTableFromEffectiveDate", func(t *testing.T) {
+		midYear := time.Date(2019, 7, 1, 0, 0, 0, 0, time.UTC)
+		// The mid-year override is activated last
+		for _, published := range []struct {
+			effectiveFrom time.Time
+			rate          float64
+		}{{rate: 0.06}, {effectiveFrom: midYear, rate: 0.12}} {
+			if _, err := overrides.PublishTaxBracketOverride("on", "2019", published.effectiveFrom, []entity.TaxBracket{{Min: 0, Rate: published.rate}}, "alice", ""); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if _, err := overrides.ActivateTaxBracketOverride("ON", "2019", "bob"); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+		}
+
+		for _, tc := range []struct {
+			asOf          time.Time
+			rate          float64
+			effectiveFrom time.Time
+		}{
+			{asOf: time.Date(2018, 12, 1, 0, 0, 0, 0, time.UTC), rate: 0.06, effectiveFrom: time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)},
+			{asOf: time.Date(2019, 3, 1, 0, 0, 0, 0, time.UTC), rate: 0.06, effectiveFrom: time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)},
+			{asOf: time.Date(2019, 8, 1, 0, 0, 0, 0, time.UTC), rate: 0.12, effectiveFrom: midYear},
+		} {
+			taxBrackets, err := newService().GetJurisdictionTaxBracket(service.WithAsOf(ctx, tc.asOf), "on", "2019")
+			if err != nil || taxBrackets.Source != service.TaxBracketSourceOverride || taxBrackets.TaxBrackets[0].Rate != tc.rate || !taxBrackets.EffectiveFrom.Equal(tc.effectiveFrom) {
+				t.Errorf("Expected the override at %.2f from %s as of %s, but got %+v, %v", tc.rate, tc.effectiveFrom, tc.asOf, taxBrackets, err)
+			}
+		}
+
+		taxBrackets, _ := overrides.GetTaxBracket(ctx, "2019")
+		if taxBrackets.Source == service.TaxBracketSourceOverride {
+			t.Errorf("Expected the provincial overrides not to replace the federal brackets")
+		}
+	})
+
+	t.Run("RollsBackLastActivated", func(t *testing.T) {
+		if _, err := overrides.RollbackTaxBracketOverride("on", "2019", "alice"); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		taxBrackets, _ := overrides.GetJurisdictionTaxBracket(service.WithAsOf(ctx, time.Date(2019, 8, 1, 0, 0, 0, 0, time.UTC)), "on", "2019")
+		if taxBrackets.TaxBrackets[0].Rate != 0.06 {
+			t.Errorf("Expected the override from the start of the year to be back in effect, but got %+v", taxBrackets)
+		}
+	})
+}
@@ -0,0 +1,54 @@
+package tests
+
+import (
+	"io"
+	"log"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/siparisa/interview-test-server/internal/entity"
+	"github.com/siparisa/interview-test-server/internal/service"
+)
+
+func TestBracketReportService(t *testing.T) {
+	reportFile := filepath.Join(t.TempDir(), "history.impact.jsonl")
+	newService := func() service.IBracketReportService {
+		reports, err := service.NewBracketReportService(service.NewBracketImpactService(service.NewTaxService(), service.BracketImpactOptions{}), service.BracketReportOptions{File: reportFile}, log.New(io.Discard, "", 0))
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		return reports
+	}
+	reports := newService()
+	table := func(rate float64, version int) *entity.TaxBrackets {
+		return &entity.TaxBrackets{
+			TaxBrackets:   []entity.TaxBracket{{Band: "0+", Rate: rate}},
+			Source:        service.TaxBracketSourceFile,
+			EffectiveFrom: time.Date(2022, time.January, 1, 0, 0, 0, 0, time.UTC),
+			Version:       version,
+		}
+	}
+
+	t.Run("ReportsChangeOnce", func(t *testing.T) {
+		// The file source reports the change before the history records the new table
+		reports.ReportTaxBracketChange("on", "2022", table(0.05, 0), table(0.06, 0))
+		reports.ReportTaxBracketChange("ON", "2022", table(0.05, 1), table(0.06, 2))
+		reports.ReportTaxBracketChange("ON", "2022", table(0.06, 2), table(0.07, 3))
+
+		impact := reports.GetBracketImpactReports("on", "2022")
+		if len(impact) != 2 || impact[0].Jurisdiction != "ON" || impact[1].After != "version 3 from file" {
+			t.Errorf("Expected 2 reports of the Ontario brackets, but got %+v", impact)
+		}
+		if federal := reports.GetBracketImpactReports(service.FederalJurisdiction, "2022"); len(federal) != 0 {
+			t.Errorf("Expected the federal reports to be kept apart, but got %+v", federal)
+		}
+	})
+
+	t.Run("SurvivesRestart", func(t *testing.T) {
+		impact := newService().GetBracketImpactReports("ON", "2022")
+		if len(impact) != 2 || impact[0].Before != "file brackets effective 2022-01-01" {
+			t.Errorf("Expected the reports to be restored, but got %+v", impact)
+		}
+	})
+}
@@ -0,0 +1,99 @@
+package tests
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/siparisa/interview-test-server/internal/entity"
+	"github.com/siparisa/interview-test-server/internal/service"
+)
+
+func TestValidateTaxBrackets(t *testing.T) {
+	testCases := []struct {
+		name     string
+		brackets []entity.TaxBracket
+		problems int
+	}{
+		{
+			name: "Valid",
+			brackets: []entity.TaxBracket{
+				{Min: 0, Max: 50000, Rate: 0.15},
+				{Min: 50000, Rate: 0.205},
+			},
+		},
+		{
+			name: "Unsorted",
+			brackets: []entity.TaxBracket{
+				{Min: 50000, Rate: 0.205},
+				{Min: 0, Max: 50000, Rate: 0.15},
+			},
+		},
+		{
+			name:     "Empty",
+			problems: 1,
+		},
+		{
+			name: "Gap",
+			brackets: []entity.TaxBracket{
+				{Min: 0, Max: 50000, Rate: 0.15},
+				{Min: 60000, Rate: 0.205},
+			},
+			problems: 1,
+		},
+		{
+			name: "Overlap",
+			brackets: []entity.TaxBracket{
+				{Min: 0, Max: 50000, Rate: 0.15},
+				{Min: 40000, Rate: 0.205},
+			},
+			problems: 1,
+		},
+		{
+			name: "RateAboveOne",
+			brackets: []entity.TaxBracket{
+				{Min: 0, Max: 50000, Rate: 15},
+				{Min: 50000, Rate: 0.205},
+			},
+			problems: 1,
+		},
+		{
+			name: "MissingOpenTopBracket",
+			brackets: []entity.TaxBracket{
+				{Min: 0, Max: 50000, Rate: 0.15},
+				{Min: 50000, Max: 100000, Rate: 0.205},
+			},
+			problems: 1,
+		},
+		{
+			name: "EveryProblem",
+			brackets: []entity.TaxBracket{
+				{Min: 10000, Max: 50000, Rate: 0.15},
+				{Min: 40000, Max: 60000, Rate: 2},
+				{Min: 70000, Max: 100000, Rate: 0.26},
+			},
+			problems: 5,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			taxBrackets := &entity.TaxBrackets{TaxBrackets: tc.brackets}
+			err := service.ValidateTaxBrackets(taxBrackets)
+
+			if tc.problems == 0 {
+				if err != nil {
+					t.Fatalf("Unexpected error: %v", err)
+				}
+				if taxBrackets.TaxBrackets[0].Min != 0 {
+					t.Errorf("Expected the brackets to be sorted, but got %+v", taxBrackets.TaxBrackets)
+				}
+				return
+			}
+
+			var validationErr *service.BracketValidationError
+			if !errors.As(err, &validationErr) || len(validationErr.Problems) != tc.problems {
+				t.Errorf("Expected %d problems, but got %v", tc.problems, err)
+			}
+		})
+	}
+}
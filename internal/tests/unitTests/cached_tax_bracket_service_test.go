@@ -0,0 +1,147 @@
+package tests
+
+import (
+	"context"
+	"io"
+	"log"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/siparisa/interview-test-server/internal/entity"
+	"github.com/siparisa/interview-test-server/internal/service"
+)
+
+// slowTaxBracketService holds every fetch long enough for concurrent callers to pile up.
+type slowTaxBracketService struct {
+	mockSwitchableTaxBracketService
+}
+
+func (m *slowTaxBracketService) GetTaxBracket(ctx context.Context, taxYear string) (*entity.TaxBrackets, error) {
+	time.Sleep(50 * time.Millisecond)
+	return m.mockSwitchableTaxBracketService.GetTaxBracket(ctx, taxYear)
+}
+
+func TestCachedTaxBracketService(t *testing.T) {
+	t.Run("CoalescesConcurrentMisses", func(t *testing.T) {
+		upstream := &slowTaxBracketService{}
+		cache := service.NewCachedTaxBracketService(upstream, nil, service.CacheOptions{})
+
+		var wg sync.WaitGroup
+		for i := 0; i < 500; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if _, err := cache.GetTaxBracket(context.Background(), "2022"); err != nil {
+					t.Errorf("Unexpected error: %v", err)
+				}
+			}()
+		}
+		wg.Wait()
+
+		if calls := upstream.callCount(); calls != 1 {
+			t.Errorf("Expected exactly 1 upstream fetch, but got %d", calls)
+		}
+	})
+
+	t.Run("HitsAndMisses", func(t *testing.T) {
+		upstream := &mockSwitchableTaxBracketService{}
+		cache := service.NewCachedTaxBracketService(upstream, nil, service.CacheOptions{})
+
+		for i := 0; i < 3; i++ {
+			cache.GetTaxBracket(context.Background(), "2019")
+		}
+
+		stats := cache.GetCacheStats()
+		if stats.Misses != 1 || stats.Hits != 2 || stats.Entries != 1 {
+			t.Errorf("Expected 1 miss, 2 hits and 1 entry, but got %+v", stats)
+		}
+		if upstream.callCount() != 1 {
+			t.Errorf("Expected 1 upstream fetch, but got %d", upstream.callCount())
+		}
+	})
+
+	t.Run("ExpiresOpenYears", func(t *testing.T) {
+		upstream := &mockSwitchableTaxBracketService{}
+		cache := service.NewCachedTaxBracketService(upstream, nil, service.CacheOptions{TTL: 10 * time.Millisecond})
+		currentYear := time.Now().Format("2006")
+
+		cache.GetTaxBracket(context.Background(), currentYear)
+		time.Sleep(20 * time.Millisecond)
+		cache.GetTaxBracket(context.Background(), currentYear)
+
+		// The expired entry is refreshed in the background.
+		for i := 0; i < 100 && upstream.callCount() < 2; i++ {
+			time.Sleep(time.Millisecond)
+		}
+		if upstream.callCount() != 2 {
+			t.Errorf("Expected the expired entry to be fetched again, but got %d fetches", upstream.callCount())
+		}
+	})
+
+	t.Run("ReportsChangedRefresh", func(t *testing.T) {
+		reports, err := service.NewBracketReportService(service.NewBracketImpactService(service.NewTaxService(), service.BracketImpactOptions{}), service.BracketReportOptions{}, log.New(io.Discard, "", 0))
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		upstream := &stubTaxBracketService{rate: 0.15}
+		cache := service.NewCachedTaxBracketService(upstream, reports, service.CacheOptions{TTL: 10 * time.Millisecond})
+		currentYear := time.Now().Format("2006")
+
+		cache.GetTaxBracket(context.Background(), currentYear)
+		upstream.setRate(0.2)
+		time.Sleep(20 * time.Millisecond)
+		cache.GetTaxBracket(context.Background(), currentYear)
+
+		var impact []entity.BracketImpactReport
+		for i := 0; i < 100 && len(impact) == 0; i++ {
+			impact = reports.GetBracketImpactReports(service.FederalJurisdiction, currentYear)
+			time.Sleep(time.Millisecond)
+		}
+		if len(impact) != 1 || len(impact[0].BandChanges) != 1 || !strings.HasPrefix(impact[0].After, "remote brackets") {
+			t.Errorf("Expected a report of the refreshed table, but got %+v", impact)
+		}
+	})
+}
+
+func TestCachedTaxBracketServiceServesStale(t *testing.T) {
+	currentYear := time.Now().Format("2006")
+
+	t.Run("ServesStaleWhileUpstreamFails", func(t *testing.T) {
+		upstream := &mockSwitchableTaxBracketService{}
+		cache := service.NewCachedTaxBracketService(upstream, nil, service.CacheOptions{TTL: 10 * time.Millisecond})
+
+		fresh, err := cache.GetTaxBracket(context.Background(), currentYear)
+		if err != nil || fresh.Stale || fresh.AsOf.IsZero() {
+			t.Fatalf("Expected fresh brackets with a timestamp, but got %+v, %v", fresh, err)
+		}
+
+		upstream.setDown(true)
+		time.Sleep(20 * time.Millisecond)
+
+		stale, err := cache.GetTaxBracket(context.Background(), currentYear)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if !stale.Stale || !stale.AsOf.Equal(fresh.AsOf) {
+			t.Errorf("Expected the stale brackets as of %s, but got %+v", fresh.AsOf, stale)
+		}
+	})
+
+	t.Run("FailsPastMaxStaleness", func(t *testing.T) {
+		upstream := &mockSwitchableTaxBracketService{}
+		cache := service.NewCachedTaxBracketService(upstream, nil, service.CacheOptions{
+			TTL:          10 * time.Millisecond,
+			MaxStaleness: 10 * time.Millisecond,
+		})
+
+		cache.GetTaxBracket(context.Background(), currentYear)
+		upstream.setDown(true)
+		time.Sleep(30 * time.Millisecond)
+
+		if _, err := cache.GetTaxBracket(context.Background(), currentYear); err == nil {
+			t.Errorf("Expected an error past the maximum staleness")
+		}
+	})
+}
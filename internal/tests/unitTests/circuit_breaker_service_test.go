@@ -0,0 +1,85 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"testing"
+	"time"
+
+	"github.com/siparisa/interview-test-server/internal/service"
+)
+
+func TestCircuitBreakerTaxBracketService(t *testing.T) {
+	upstream := &mockSwitchableTaxBracketService{}
+	breaker := service.NewCircuitBreakerTaxBracketService(upstream, service.CircuitBreakerOptions{
+		FailureThreshold: 2,
+		CoolDown:         50 * time.Millisecond,
+	}, log.New(io.Discard, "", 0))
+	ctx := context.Background()
+
+	// Cache the brackets for 2019 while the upstream is healthy
+	if _, err := breaker.GetTaxBracket(ctx, "2019"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	upstream.setDown(true)
+	for i := 0; i < 2; i++ {
+		breaker.GetTaxBracket(ctx, "2020")
+	}
+
+	t.Run("OpensAfterThreshold", func(t *testing.T) {
+		if state := breaker.GetCircuitBreakerStatus().State; state != service.CircuitOpen {
+			t.Fatalf("Expected the breaker to be open, but got %s", state)
+		}
+	})
+
+	t.Run("FailsFastWhileOpen", func(t *testing.T) {
+		calls := upstream.callCount()
+		_, err := breaker.GetTaxBracket(ctx, "2020")
+		if !errors.Is(err, service.ErrCircuitOpen) {
+			t.Errorf("Expected ErrCircuitOpen, but got %v", err)
+		}
+		if upstream.callCount() != calls {
+			t.Errorf("Expected no upstream call while the breaker is open")
+		}
+	})
+
+	t.Run("FallsBackToCachedBrackets", func(t *testing.T) {
+		taxBrackets, err := breaker.GetTaxBracket(ctx, "2019")
+		if err != nil || taxBrackets == nil {
+			t.Errorf("Expected the cached 2019 brackets, but got %v", err)
+		}
+	})
+
+	t.Run("ClosesAfterSuccessfulTrial", func(t *testing.T) {
+		upstream.setDown(false)
+		time.Sleep(60 * time.Millisecond)
+
+		if _, err := breaker.GetTaxBracket(ctx, "2020"); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if state := breaker.GetCircuitBreakerStatus().State; state != service.CircuitClosed {
+			t.Errorf("Expected the breaker to be closed, but got %s", state)
+		}
+	})
+}
+
+func TestCircuitBreakerIgnoresInvalidRequests(t *testing.T) {
+	// A malformed tax calculator URL is a configuration error, not an upstream failure
+	invalidURL, _ := service.NewTaxBracketService("http://tax calculator\x7f/tax-year/", service.TaxBracketServiceOptions{})
+	breaker := service.NewCircuitBreakerTaxBracketService(invalidURL, service.CircuitBreakerOptions{FailureThreshold: 1}, log.New(io.Discard, "", 0))
+
+	for i := 0; i < 2; i++ {
+		_, err := breaker.GetTaxBracket(context.Background(), "2019")
+
+		var fetchErr *service.BracketFetchError
+		if !errors.As(err, &fetchErr) || fetchErr.Reason != service.FetchFailureRequest {
+			t.Fatalf("Expected a request failure, but got %v", err)
+		}
+	}
+	if state := breaker.GetCircuitBreakerStatus().State; state != service.CircuitClosed {
+		t.Errorf("Expected the breaker to stay closed, but got %s", state)
+	}
+}
@@ -1,21 +1,30 @@
 package tests
 
 import (
+	"context"
 	"encoding/json"
+	"io"
+	"log"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/siparisa/interview-test-server/internal/controller"
 	"github.com/siparisa/interview-test-server/internal/controller/helper"
+	"github.com/siparisa/interview-test-server/internal/service"
 )
 
 func TestGetTotalIncomeTax(t *testing.T) {
 	router := gin.New()
 	taxService := &mockTaxService{}
 	taxBracketService := &mockTaxBracketService{}
-	taxController := controller.NewTaxController(taxService, taxBracketService)
+	taxCreditService := &mockTaxCreditService{}
+	provincialTaxService := &mockProvincialTaxService{}
+	payrollContributionService := &mockPayrollContributionService{}
+	taxController := controller.NewTaxController(taxService, taxBracketService, &mockTaxYearService{}, taxCreditService, provincialTaxService, payrollContributionService)
 	router.Handle(http.MethodGet, "/calculate-tax", taxController.GetTotalIncomeTax)
 
 	t.Run("InvalidSalaryInput", func(t *testing.T) {
@@ -36,6 +45,24 @@ func TestGetTotalIncomeTax(t *testing.T) {
 		}
 	})
 
+	t.Run("InvalidAsOfInput", func(t *testing.T) {
+		req, _ := http.NewRequest(http.MethodGet, "/calculate-tax?salary=50000&year=2019&asOf=2019-13-01", nil)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("Expected status code %d, but got %d", http.StatusBadRequest, rec.Code)
+		}
+	})
+
+	t.Run("InvalidProvincialBracketVersionInput", func(t *testing.T) {
+		req, _ := http.NewRequest(http.MethodGet, "/calculate-tax?salary=50000&year=2019&province=ON&provincialBracketVersion=latest", nil)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "ProvincialBracketVersion must be a numeric value") {
+			t.Errorf("Expected status code %d naming the provincial bracket version, but got %d: %s", http.StatusBadRequest, rec.Code, rec.Body.String())
+		}
+	})
+
 	t.Run("InvalidTaxYearInput", func(t *testing.T) {
 		req, _ := http.NewRequest(http.MethodGet, "/calculate-tax?salary=50000&year=invalid", nil)
 		rec := httptest.NewRecorder()
@@ -111,4 +138,379 @@ func TestGetTotalIncomeTax(t *testing.T) {
 			t.Errorf("Expected status code %d, but got %d", http.StatusBadRequest, rec.Code)
 		}
 	})
+
+	t.Run("TestGetTotalIncomeTaxWithDonations", func(t *testing.T) {
+		req, _ := http.NewRequest(http.MethodGet, "/calculate-tax?salary=50000&year=2019&donations=1000", nil)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Errorf("Expected status code %d, but got %d", http.StatusOK, rec.Code)
+		}
+
+		var response helper.TaxAmountResponse
+		err := json.Unmarshal(rec.Body.Bytes(), &response)
+		if err != nil {
+			t.Fatalf("Failed to unmarshal response: %v", err)
+		}
+
+		expectedTotalTaxAmount := 7480.35
+		if response.TotalTaxAmount != expectedTotalTaxAmount {
+			t.Errorf("Expected total tax amount %f, but got %f", expectedTotalTaxAmount, response.TotalTaxAmount)
+		}
+		if len(response.TaxCredits) != 1 {
+			t.Errorf("Expected 1 itemized tax credit, but got %d", len(response.TaxCredits))
+		}
+	})
+
+	t.Run("TestGetTotalIncomeTaxWithNegativeMedicalExpenses", func(t *testing.T) {
+		req, _ := http.NewRequest(http.MethodGet, "/calculate-tax?salary=50000&year=2019&medicalExpenses=-100", nil)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("Expected status code %d, but got %d", http.StatusBadRequest, rec.Code)
+		}
+	})
+
+	t.Run("TestGetTotalIncomeTaxForQuebec", func(t *testing.T) {
+		req, _ := http.NewRequest(http.MethodGet, "/calculate-tax?salary=50000&year=2019&province=qc", nil)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Errorf("Expected status code %d, but got %d", http.StatusOK, rec.Code)
+		}
+
+		var response helper.TaxAmountResponse
+		err := json.Unmarshal(rec.Body.Bytes(), &response)
+		if err != nil {
+			t.Fatalf("Failed to unmarshal response: %v", err)
+		}
+
+		expectedTotalTaxAmount := 6371.34
+		if response.TotalTaxAmount != expectedTotalTaxAmount {
+			t.Errorf("Expected total tax amount %f, but got %f", expectedTotalTaxAmount, response.TotalTaxAmount)
+		}
+		expectedCombinedTaxAmount := 11894.49
+		if response.TotalCombinedTaxAmount != expectedCombinedTaxAmount {
+			t.Errorf("Expected combined tax amount %f, but got %f", expectedCombinedTaxAmount, response.TotalCombinedTaxAmount)
+		}
+		if response.ProvincialTax == nil || response.ProvincialTax.Province != "QC" {
+			t.Errorf("Expected Quebec provincial tax in the response")
+		}
+	})
+
+	t.Run("TestGetTotalIncomeTaxWithUnsupportedProvince", func(t *testing.T) {
+		req, _ := http.NewRequest(http.MethodGet, "/calculate-tax?salary=50000&year=2019&province=XX", nil)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("Expected status code %d, but got %d", http.StatusBadRequest, rec.Code)
+		}
+	})
+}
+
+func TestGetTotalIncomeTaxUpstreamTimeout(t *testing.T) {
+	router := gin.New()
+	taxController := controller.NewTaxController(&mockTaxService{}, &mockTaxBracketService{}, &mockTaxYearService{}, &mockTaxCreditService{}, &mockProvincialTaxService{}, &mockPayrollContributionService{})
+	router.Handle(http.MethodGet, "/calculate-tax", taxController.GetTotalIncomeTax)
+
+	req, _ := http.NewRequest(http.MethodGet, "/calculate-tax?salary=50000&year=2022", nil)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	if rec.Code != http.StatusGatewayTimeout {
+		t.Errorf("Expected status code %d, but got %d", http.StatusGatewayTimeout, rec.Code)
+	}
+}
+
+func TestTaxYearWithoutPublishedRules(t *testing.T) {
+	// The bracket source serves 2023 but the credit, benefit and payroll rules stop at 2022
+	router := gin.New()
+	taxService := service.NewTaxService()
+	provincialTaxService := service.NewProvincialTaxService(taxService, service.NewJurisdictionTaxBracketService(&mockSwitchableTaxBracketService{}, nil))
+	payrollContributionService := service.NewPayrollContributionService()
+	taxYearService := &stubTaxYearService{taxYears: []string{"2023"}}
+	taxController := controller.NewTaxController(taxService, &mockSwitchableTaxBracketService{}, taxYearService, service.NewTaxCreditService(), provincialTaxService, payrollContributionService)
+	marginalRateService := service.NewMarginalRateService(taxService, provincialTaxService, payrollContributionService, service.NewBenefitService())
+	marginalRateController := controller.NewMarginalRateController(&mockSwitchableTaxBracketService{}, taxYearService, marginalRateService)
+	router.Handle(http.MethodGet, "/calculate-tax", taxController.GetTotalIncomeTax)
+	router.Handle(http.MethodGet, "/marginal-rate", marginalRateController.GetMarginalEffectiveRate)
+
+	for _, tc := range []struct {
+		name   string
+		url    string
+		status int
+	}{
+		{name: "WithoutCredits", url: "/calculate-tax?salary=50000&year=2023", status: http.StatusOK},
+		{name: "WithDonations", url: "/calculate-tax?salary=50000&year=2023&donations=1000", status: http.StatusUnprocessableEntity},
+		{name: "WithProvince", url: "/calculate-tax?salary=50000&year=2023&province=ON", status: http.StatusUnprocessableEntity},
+		{name: "MarginalRate", url: "/marginal-rate?salary=50000&year=2023", status: http.StatusUnprocessableEntity},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodGet, tc.url, nil)
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+			if rec.Code != tc.status {
+				t.Errorf("Expected status code %d, but got %d: %s", tc.status, rec.Code, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestGetBenefits(t *testing.T) {
+	router := gin.New()
+	benefitController := controller.NewBenefitController(&mockBenefitService{})
+	router.Handle(http.MethodGet, "/benefits", benefitController.GetBenefits)
+
+	t.Run("Success", func(t *testing.T) {
+		req, _ := http.NewRequest(http.MethodGet, "/benefits?salary=40000&spouseSalary=20000&year=2021&maritalStatus=married&childrenUnder6=1", nil)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Errorf("Expected status code %d, but got %d", http.StatusOK, rec.Code)
+		}
+
+		var response helper.BenefitEstimateResponse
+		err := json.Unmarshal(rec.Body.Bytes(), &response)
+		if err != nil {
+			t.Fatalf("Failed to unmarshal response: %v", err)
+		}
+
+		expectedFamilyNetIncome := 60000.0
+		if response.FamilyNetIncome != expectedFamilyNetIncome {
+			t.Errorf("Expected family net income %f, but got %f", expectedFamilyNetIncome, response.FamilyNetIncome)
+		}
+	})
+
+	t.Run("InvalidMaritalStatus", func(t *testing.T) {
+		req, _ := http.NewRequest(http.MethodGet, "/benefits?salary=40000&year=2021&maritalStatus=widowed", nil)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("Expected status code %d, but got %d", http.StatusBadRequest, rec.Code)
+		}
+	})
+
+	t.Run("SpouseSalaryForSingle", func(t *testing.T) {
+		req, _ := http.NewRequest(http.MethodGet, "/benefits?salary=40000&spouseSalary=20000&year=2021&maritalStatus=single", nil)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("Expected status code %d, but got %d", http.StatusBadRequest, rec.Code)
+		}
+	})
+}
+
+func TestGetMarginalEffectiveRate(t *testing.T) {
+	router := gin.New()
+	taxService := service.NewTaxService()
+	provincialTaxService := service.NewProvincialTaxService(taxService, service.NewJurisdictionTaxBracketService(&mockTaxBracketService{}, nil))
+	marginalRateService := service.NewMarginalRateService(taxService, provincialTaxService, service.NewPayrollContributionService(), service.NewBenefitService())
+	marginalRateController := controller.NewMarginalRateController(&mockTaxBracketService{}, &mockTaxYearService{}, marginalRateService)
+	router.Handle(http.MethodGet, "/marginal-rate", marginalRateController.GetMarginalEffectiveRate)
+
+	t.Run("SpouseSalaryForMarried", func(t *testing.T) {
+		req, _ := http.NewRequest(http.MethodGet, "/marginal-rate?salary=40000&spouseSalary=20000&year=2019&maritalStatus=married", nil)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Errorf("Expected status code %d, but got %d: %s", http.StatusOK, rec.Code, rec.Body.String())
+		}
+	})
+
+	t.Run("SpouseSalaryForSingle", func(t *testing.T) {
+		req, _ := http.NewRequest(http.MethodGet, "/marginal-rate?salary=40000&spouseSalary=20000&year=2019&maritalStatus=single", nil)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("Expected status code %d, but got %d", http.StatusBadRequest, rec.Code)
+		}
+	})
+}
+
+func TestGetTaxYears(t *testing.T) {
+	router := gin.New()
+	taxYearController := controller.NewTaxYearController(&mockTaxYearService{})
+	router.Handle(http.MethodGet, "/years", taxYearController.GetTaxYears)
+
+	req, _ := http.NewRequest(http.MethodGet, "/years", nil)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status code %d, but got %d", http.StatusOK, rec.Code)
+	}
+
+	var response helper.TaxYearsResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to unmarshal response body: %v", err)
+	}
+	if len(response.TaxYears) != 2 || response.TaxYears[1].Year != "2022" || response.TaxYears[1].Source != "remote" {
+		t.Errorf("Unexpected tax years: %+v", response.TaxYears)
+	}
+}
+
+func TestGetTaxBrackets(t *testing.T) {
+	router := gin.New()
+	bracketController := controller.NewBracketController(&mockTaxBracketService{}, &mockTaxYearService{}, &mockProvincialTaxService{})
+	router.Handle(http.MethodGet, "/brackets/*table", bracketController.GetTaxBrackets)
+
+	testCases := []struct {
+		name         string
+		path         string
+		statusCode   int
+		jurisdiction string
+		bands        int
+	}{
+		{name: "Federal", path: "/brackets/2019", statusCode: http.StatusOK, jurisdiction: "federal", bands: 5},
+		{name: "FederalJurisdiction", path: "/brackets/federal/2019", statusCode: http.StatusOK, jurisdiction: "federal", bands: 5},
+		{name: "Provincial", path: "/brackets/qc/2019", statusCode: http.StatusOK, jurisdiction: "QC", bands: 1},
+		{name: "UnsupportedYear", path: "/brackets/2020", statusCode: http.StatusNotFound},
+		{name: "UnsupportedJurisdiction", path: "/brackets/XX/2019", statusCode: http.StatusNotFound},
+		{name: "UpstreamTimeout", path: "/brackets/2022", statusCode: http.StatusGatewayTimeout},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodGet, tc.path, nil)
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			if rec.Code != tc.statusCode {
+				t.Fatalf("Expected status code %d, but got %d", tc.statusCode, rec.Code)
+			}
+			if tc.statusCode != http.StatusOK {
+				return
+			}
+
+			var response helper.TaxBracketsResponse
+			if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil {
+				t.Fatalf("Failed to unmarshal response body: %v", err)
+			}
+			if response.Jurisdiction != tc.jurisdiction || response.Year != "2019" || len(response.TaxBrackets) != tc.bands {
+				t.Errorf("Unexpected tax brackets: %+v", response)
+			}
+		})
+	}
+}
+
+func TestAdminBracketOverrides(t *testing.T) {
+	overrides, err := service.NewBracketOverrideService(service.NewJurisdictionTaxBracketService(&mockTaxBracketService{}, nil), service.NewBracketImpactService(service.NewTaxService(), service.BracketImpactOptions{}), service.BracketOverrideOptions{}, log.New(io.Discard, "", 0))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	adminController := controller.NewAdminController(overrides, &mockTaxBracketService{}, &mockTaxYearService{})
+
+	router := gin.New()
+	adminGroup := router.Group("/admin", helper.AdminAuth(map[string]string{"secret": "alice"}))
+	adminGroup.PUT("/brackets/:jurisdiction/:year", adminController.PublishBracketOverride)
+	adminGroup.POST("/brackets/:jurisdiction/:year/activate", adminController.ActivateBracketOverride)
+
+	testCases := []struct {
+		name       string
+		method     string
+		path       string
+		token      string
+		body       string
+		statusCode int
+	}{
+		{name: "MissingToken", method: http.MethodPut, path: "/admin/brackets/federal/2019", body: `{"tax_brackets":[{"min":0,"rate":0.2}]}`, statusCode: http.StatusUnauthorized},
+		{name: "InvalidToken", method: http.MethodPut, path: "/admin/brackets/federal/2019", token: "guess", body: `{"tax_brackets":[{"min":0,"rate":0.2}]}`, statusCode: http.StatusUnauthorized},
+		{name: "InvalidTable", method: http.MethodPut, path: "/admin/brackets/federal/2019", token: "secret", body: `{"tax_brackets":[{"min":10,"rate":0.2}]}`, statusCode: http.StatusUnprocessableEntity},
+		{name: "UnsupportedYear", method: http.MethodPut, path: "/admin/brackets/federal/2020", token: "secret", body: `{"tax_brackets":[{"min":0,"rate":0.2}]}`, statusCode: http.StatusNotFound},
+		{name: "NothingStaged", method: http.MethodPost, path: "/admin/brackets/federal/2019/activate", token: "secret", statusCode: http.StatusNotFound},
+		{name: "Publish", method: http.MethodPut, path: "/admin/brackets/federal/2019", token: "secret", body: `{"tax_brackets":[{"min":0,"rate":0.2}]}`, statusCode: http.StatusOK},
+		{name: "Activate", method: http.MethodPost, path: "/admin/brackets/federal/2019/activate", token: "secret", statusCode: http.StatusOK},
+		{name: "UnsupportedJurisdiction", method: http.MethodPut, path: "/admin/brackets/bc/2019", token: "secret", body: `{"tax_brackets":[{"min":0,"rate":0.05}]}`, statusCode: http.StatusNotFound},
+		{name: "InvalidEffectiveDate", method: http.MethodPut, path: "/admin/brackets/on/2019", token: "secret", body: `{"tax_brackets":[{"min":0,"rate":0.05}],"effective_from":"2019-13-01"}`, statusCode: http.StatusBadRequest},
+		{name: "EffectiveDateOutsideTaxYear", method: http.MethodPut, path: "/admin/brackets/on/2019", token: "secret", body: `{"tax_brackets":[{"min":0,"rate":0.05}],"effective_from":"2020-01-01"}`, statusCode: http.StatusBadRequest},
+		{name: "PublishProvincial", method: http.MethodPut, path: "/admin/brackets/on/2019", token: "secret", body: `{"tax_brackets":[{"min":0,"rate":0.05}],"effective_from":"2019-07-01"}`, statusCode: http.StatusOK},
+		{name: "ActivateProvincial", method: http.MethodPost, path: "/admin/brackets/ON/2019/activate", token: "secret", statusCode: http.StatusOK},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req, _ := http.NewRequest(tc.method, tc.path, strings.NewReader(tc.body))
+			if tc.token != "" {
+				req.Header.Set("Authorization", "Bearer "+tc.token)
+			}
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			if rec.Code != tc.statusCode {
+				t.Errorf("Expected status code %d, but got %d: %s", tc.statusCode, rec.Code, rec.Body.String())
+			}
+		})
+	}
+
+	if auditLog := overrides.GetBracketAuditLog(); len(auditLog) != 4 || auditLog[0].Actor != "alice" || auditLog[2].Jurisdiction != "ON" {
+		t.Errorf("Expected the publishes and activations by alice to be audited, but got %+v", auditLog)
+	}
+}
+
+func TestGetStatus(t *testing.T) {
+	router := gin.New()
+	logger := log.New(io.Discard, "", 0)
+	primary := service.NewCircuitBreakerTaxBracketService(&mockSwitchableTaxBracketService{}, service.CircuitBreakerOptions{FailureThreshold: 1}, logger)
+	secondary := service.NewCircuitBreakerTaxBracketService(&mockSwitchableTaxBracketService{down: true}, service.CircuitBreakerOptions{FailureThreshold: 1, CoolDown: time.Minute}, logger)
+	secondary.GetTaxBracket(context.Background(), "2019")
+
+	cache := service.NewCachedTaxBracketService(primary, nil, service.CacheOptions{})
+	sourceChain := service.NewSourceChainTaxBracketService([]service.BracketSource{
+		{Name: service.TaxBracketSourceRemote, Service: cache},
+		{Name: service.TaxBracketSourceRemoteSecondary, Service: secondary},
+	}, service.SourceChainOptions{}, logger)
+	warmUpService := service.NewWarmUpService(sourceChain, &mockTaxYearService{}, service.WarmUpOptions{}, logger)
+	statusController := controller.NewStatusController(map[string]service.ICircuitBreakerTaxBracketService{
+		service.TaxBracketSourceRemote:          primary,
+		service.TaxBracketSourceRemoteSecondary: secondary,
+	}, cache, sourceChain, nil, warmUpService)
+	router.Handle(http.MethodGet, "/status", statusController.GetStatus)
+
+	req, _ := http.NewRequest(http.MethodGet, "/status", nil)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	var response helper.StatusResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if response.Status != "degraded" || len(response.CircuitBreakers) != 2 {
+		t.Fatalf("Expected a degraded status with both circuit breakers, but got %s", rec.Body.String())
+	}
+	if response.CircuitBreakers[service.TaxBracketSourceRemote].State != service.CircuitClosed || response.CircuitBreakers[service.TaxBracketSourceRemoteSecondary].State != service.CircuitOpen {
+		t.Errorf("Expected the primary breaker closed and the secondary open, but got %+v", response.CircuitBreakers)
+	}
+}
+
+func TestGetReadiness(t *testing.T) {
+	router := gin.New()
+	upstream := &mockSwitchableTaxBracketService{down: true}
+	warmUpService := service.NewWarmUpService(upstream, &mockTaxYearService{}, service.WarmUpOptions{RetryInterval: 10 * time.Millisecond}, log.New(io.Discard, "", 0))
+	statusController := controller.NewStatusController(nil, nil, nil, nil, warmUpService)
+	router.Handle(http.MethodGet, "/ready", statusController.GetReadiness)
+
+	t.Run("NotReadyWhileWarmingUp", func(t *testing.T) {
+		req, _ := http.NewRequest(http.MethodGet, "/ready", nil)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		if rec.Code != http.StatusServiceUnavailable {
+			t.Errorf("Expected status code %d, but got %d", http.StatusServiceUnavailable, rec.Code)
+		}
+	})
+
+	t.Run("ReadyOnceWarmedUp", func(t *testing.T) {
+		upstream.setDown(false)
+		waitForWarmUp(t, warmUpService)
+
+		req, _ := http.NewRequest(http.MethodGet, "/ready", nil)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Expected status code %d, but got %d", http.StatusOK, rec.Code)
+		}
+
+		var response helper.ReadinessResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil || !response.Ready || response.WarmUp.State != service.WarmUpComplete {
+			t.Errorf("Expected a complete warm-up, but got %s", rec.Body.String())
+		}
+	})
 }
@@ -0,0 +1,47 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/siparisa/interview-test-server/internal/service"
+)
+
+func TestEmbeddedTaxBracketService(t *testing.T) {
+	embedded, err := service.NewEmbeddedTaxBracketService()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	t.Run("ServesEverySupportedYear", func(t *testing.T) {
+		for _, year := range []string{"2019", "2020", "2021", "2022"} {
+			taxBrackets, err := embedded.GetTaxBracket(context.Background(), year)
+			if err != nil {
+				t.Fatalf("Unexpected error for %s: %v", year, err)
+			}
+			if len(taxBrackets.TaxBrackets) != 5 || taxBrackets.Source != service.TaxBracketSourceEmbedded {
+				t.Errorf("Expected 5 embedded brackets for %s, but got %+v", year, taxBrackets)
+			}
+		}
+	})
+
+	t.Run("Matches2022Table", func(t *testing.T) {
+		taxBrackets, _ := embedded.GetTaxBracket(context.Background(), "2022")
+		top := taxBrackets.TaxBrackets[4]
+		if top.Band != "221708+" || top.Label != "33% over $221,708" || top.Min != 221708 || top.Rate != 0.33 {
+			t.Errorf("Unexpected top bracket: %+v", top)
+		}
+
+		second := taxBrackets.TaxBrackets[1]
+		if second.Band != "50197-100392" || second.Label != "20.5% on $50,197 to $100,392" {
+			t.Errorf("Unexpected second bracket: %+v", second)
+		}
+	})
+
+	t.Run("UnknownYear", func(t *testing.T) {
+		if _, err := embedded.GetTaxBracket(context.Background(), "1999"); !errors.Is(err, service.ErrTaxYearNotFound) {
+			t.Errorf("Expected ErrTaxYearNotFound, but got %v", err)
+		}
+	})
+}
@@ -0,0 +1,252 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/siparisa/interview-test-server/internal/entity"
+	"github.com/siparisa/interview-test-server/internal/service"
+)
+
+const yamlBrackets = `tax_brackets:
+  - {band: lowest, label: Lowest rate, min: 0, max: 50000, rate: 0.15}
+  - {min: 50000, rate: 0.25}
+`
+
+const csvBrackets = `min,max,rate
+0,40000,0.0505
+40000,,0.0915
+`
+
+// writeBracketFile writes a bracket table file with a modification time that differs from any previous write.
+func writeBracketFile(t *testing.T, dir string, name string, content string, modTime time.Time) {
+	t.Helper()
+
+	path := filepath.Join(dir, name)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(path, modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// syncBuffer is a bytes.Buffer that can be written by a logger while the test reads it.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.buf.String()
+}
+
+// waitForTopRate waits for the reloaded federal top band rate of the year.
+func waitForTopRate(s service.ITaxBracketService, taxYear string, rate float64) bool {
+	for i := 0; i < 200; i++ {
+		taxBrackets, err := s.GetTaxBracket(context.Background(), taxYear)
+		if err == nil && taxBrackets.TaxBrackets[len(taxBrackets.TaxBrackets)-1].Rate == rate {
+			return true
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	return false
+}
+
+func TestFileTaxBracketService(t *testing.T) {
+	dir := t.TempDir()
+	modTime := time.Now().Add(-time.Hour)
+	writeBracketFile(t, dir, "federal/2022.yaml", yamlBrackets, modTime)
+	writeBracketFile(t, dir, "federal/2021.json", `{"tax_brackets": [{"min": 0, "max": 49020, "rate": 0.15}, {"min": 49020, "rate": 0.205}]}`, modTime)
+	writeBracketFile(t, dir, "on/2022.csv", csvBrackets, modTime)
+
+	reports, err := service.NewBracketReportService(service.NewBracketImpactService(service.NewTaxService(), service.BracketImpactOptions{}), service.BracketReportOptions{}, log.New(io.Discard, "", 0))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	files, err := service.NewFileTaxBracketService(service.FileTaxBracketOptions{
+		Dir:          dir,
+		PollInterval: 5 * time.Millisecond,
+	}, reports, log.New(io.Discard, "", 0))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer files.Close()
+
+	t.Run("ReadsEveryFormat", func(t *testing.T) {
+		for _, tc := range []struct{ jurisdiction, year string }{{"federal", "2022"}, {"federal", "2021"}, {"ON", "2022"}} {
+			taxBrackets, err := files.GetJurisdictionTaxBracket(context.Background(), tc.jurisdiction, tc.year)
+			if err != nil {
+				t.Fatalf("Unexpected error for %s %s: %v", tc.jurisdiction, tc.year, err)
+			}
+			if len(taxBrackets.TaxBrackets) != 2 || taxBrackets.Source != service.TaxBracketSourceFile {
+				t.Errorf("Expected 2 brackets from the file for %s %s, but got %+v", tc.jurisdiction, tc.year, taxBrackets)
+			}
+		}
+
+		taxBrackets, _ := files.GetJurisdictionTaxBracket(context.Background(), "on", "2022")
+		if top := taxBrackets.TaxBrackets[1]; top.Min != 40000 || top.Max != 0 || top.Rate != 0.0915 {
+			t.Errorf("Unexpected top bracket from the CSV table: %+v", top)
+		}
+	})
+
+	t.Run("KeepsSourceBandNames", func(t *testing.T) {
+		taxBrackets, _ := files.GetTaxBracket(context.Background(), "2022")
+		if lowest := taxBrackets.TaxBrackets[0]; lowest.Band != "lowest" || lowest.Label != "Lowest rate" {
+			t.Errorf("Expected the band name and label of the file, but got %+v", lowest)
+		}
+		if top := taxBrackets.TaxBrackets[1]; top.Band != "50000+" || top.Label != "25% over $50,000" {
+			t.Errorf("Expected a band name and label derived from the bounds, but got %+v", top)
+		}
+	})
+
+	t.Run("SelectsVersionEffectiveOnAsOf", func(t *testing.T) {
+		writeBracketFile(t, dir, "on/2022@2022-07-01.csv", "min,max,rate\n0,40000,0.05\n40000,,0.09\n", modTime)
+		amendedOn := time.Date(2022, time.July, 1, 0, 0, 0, 0, time.UTC)
+
+		for i := 0; i < 200; i++ {
+			taxBrackets, _ := files.GetJurisdictionTaxBracket(context.Background(), "on", "2022")
+			if taxBrackets.EffectiveFrom.Equal(amendedOn) {
+				break
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+
+		march := service.WithAsOf(context.Background(), time.Date(2022, time.March, 1, 0, 0, 0, 0, time.UTC))
+		if taxBrackets, _ := files.GetJurisdictionTaxBracket(march, "on", "2022"); taxBrackets.TaxBrackets[0].Rate != 0.0505 {
+			t.Errorf("Expected the original table in March, but got %+v", taxBrackets)
+		}
+
+		august := service.WithAsOf(context.Background(), time.Date(2022, time.August, 1, 0, 0, 0, 0, time.UTC))
+		taxBrackets, _ := files.GetJurisdictionTaxBracket(august, "on", "2022")
+		if taxBrackets.TaxBrackets[0].Rate != 0.05 || !taxBrackets.EffectiveFrom.Equal(amendedOn) {
+			t.Errorf("Expected the amended table in August, but got %+v", taxBrackets)
+		}
+	})
+
+	t.Run("UnknownYear", func(t *testing.T) {
+		if _, err := files.GetTaxBracket(context.Background(), "2019"); !errors.Is(err, service.ErrTaxYearNotFound) {
+			t.Errorf("Expected ErrTaxYearNotFound, but got %v", err)
+		}
+	})
+
+	t.Run("ReloadsChangedFiles", func(t *testing.T) {
+		writeBracketFile(t, dir, "federal/2022.yaml", "tax_brackets:\n  - {min: 0, max: 50000, rate: 0.15}\n  - {min: 50000, rate: 0.3}\n", time.Now())
+
+		if !waitForTopRate(files, "2022", 0.3) {
+			t.Errorf("Expected the corrected table to be reloaded")
+		}
+	})
+
+	t.Run("ReportsReplacedTables", func(t *testing.T) {
+		writeBracketFile(t, dir, "on/2022.csv", "min,max,rate\n0,40000,0.0505\n40000,,0.1\n", time.Now())
+
+		for _, jurisdiction := range []string{service.FederalJurisdiction, "ON"} {
+			var impact []entity.BracketImpactReport
+			for i := 0; i < 200 && len(impact) == 0; i++ {
+				impact = reports.GetBracketImpactReports(jurisdiction, "2022")
+				time.Sleep(5 * time.Millisecond)
+			}
+			if len(impact) != 1 || len(impact[0].BandChanges) == 0 || !strings.HasPrefix(impact[0].Before, "file brackets effective 2022-01-01") {
+				t.Errorf("Expected a report of the replaced %s table, but got %+v", jurisdiction, impact)
+			}
+		}
+
+		// The tables of new files replace no table
+		if impact := reports.GetBracketImpactReports(service.FederalJurisdiction, "2020"); len(impact) != 0 {
+			t.Errorf("Expected no report of a new table, but got %+v", impact)
+		}
+	})
+
+	t.Run("KeepsTableOfRejectedFile", func(t *testing.T) {
+		writeBracketFile(t, dir, "federal/2021.json", `{"tax_brackets": [{"min": 0, "rate": 1.5}]}`, time.Now())
+		writeBracketFile(t, dir, "federal/2020.csv", csvBrackets, time.Now())
+
+		if !waitForTopRate(files, "2020", 0.0915) {
+			t.Fatalf("Expected the new table to be loaded")
+		}
+
+		taxBrackets, err := files.GetTaxBracket(context.Background(), "2021")
+		if err != nil || taxBrackets.TaxBrackets[1].Rate != 0.205 {
+			t.Errorf("Expected the previous 2021 table to be kept, but got %+v, %v", taxBrackets, err)
+		}
+	})
+
+	t.Run("LogsRejectedFileOncePerChange", func(t *testing.T) {
+		rejectedDir := t.TempDir()
+		writeBracketFile(t, rejectedDir, "federal/2022.json", `{"tax_brackets": [{"min": 0, "rate": 1.5}]}`, modTime)
+
+		var logs syncBuffer
+		rejectedFiles, err := service.NewFileTaxBracketService(service.FileTaxBracketOptions{
+			Dir:          rejectedDir,
+			PollInterval: 5 * time.Millisecond,
+		}, nil, log.New(&logs, "", 0))
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		defer rejectedFiles.Close()
+
+		time.Sleep(50 * time.Millisecond)
+		if count := strings.Count(logs.String(), "rejected tax bracket file"); count != 1 {
+			t.Fatalf("Expected the rejected file to be logged once, but got %d", count)
+		}
+
+		writeBracketFile(t, rejectedDir, "federal/2022.json", `{"tax_brackets": [{"min": 0, "rate": 2.5}]}`, time.Now())
+		time.Sleep(50 * time.Millisecond)
+		if count := strings.Count(logs.String(), "rejected tax bracket file"); count != 2 {
+			t.Errorf("Expected the changed rejected file to be logged again once, but got %d", count)
+		}
+	})
+
+	t.Run("RejectsInvalidFileNames", func(t *testing.T) {
+		namesDir := t.TempDir()
+		table := `{"tax_brackets": [{"min": 0, "rate": 0.15}]}`
+		for _, name := range []string{"federal/2019-backup.json", "federal/notes.json", "federal/2019@2020-03-01.json", "federal/2019@2019-02-30.json"} {
+			writeBracketFile(t, namesDir, name, table, modTime)
+		}
+		writeBracketFile(t, namesDir, "README.md", "not a table", modTime)
+
+		var logs syncBuffer
+		namedFiles, err := service.NewFileTaxBracketService(service.FileTaxBracketOptions{
+			Dir:          namesDir,
+			PollInterval: 5 * time.Millisecond,
+		}, nil, log.New(&logs, "", 0))
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		defer namedFiles.Close()
+
+		time.Sleep(50 * time.Millisecond)
+		if count := strings.Count(logs.String(), "rejected tax bracket file"); count != 4 {
+			t.Errorf("Expected the 4 misnamed files to be logged once each, but got %d: %s", count, logs.String())
+		}
+		if _, err := namedFiles.GetTaxBracket(context.Background(), "2019"); !errors.Is(err, service.ErrTaxYearNotFound) {
+			t.Errorf("Expected ErrTaxYearNotFound, but got %v", err)
+		}
+		if taxYears, _ := namedFiles.GetTaxYears(context.Background()); len(taxYears) != 0 {
+			t.Errorf("Expected no tax years, but got %v", taxYears)
+		}
+	})
+}
@@ -0,0 +1,50 @@
+package tests
+
+import (
+	"context"
+	"testing"
+
+	"github.com/siparisa/interview-test-server/internal/entity"
+	"github.com/siparisa/interview-test-server/internal/service"
+)
+
+func TestCalculateMarginalEffectiveRate(t *testing.T) {
+	taxService := service.NewTaxService()
+	marginalRateService := service.NewMarginalRateService(
+		taxService,
+		service.NewProvincialTaxService(taxService, service.NewJurisdictionTaxBracketService(&mockTaxBracketService{}, nil)),
+		service.NewPayrollContributionService(),
+		service.NewBenefitService(),
+	)
+	taxBrackets, _ := (&mockTaxBracketService{}).GetTaxBracket(context.Background(), "2021")
+
+	t.Run("ChildBenefitClawback", func(t *testing.T) {
+		result, err := marginalRateService.CalculateMarginalEffectiveRate(context.Background(), taxBrackets, "2021", entity.MarginalRateInputs{
+			Salary:         60000,
+			Delta:          1000,
+			MaritalStatus:  service.MaritalStatusMarried,
+			ChildrenUnder6: 1,
+		})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		// 20.5% federal tax, 5.45% CPP and the 7% Canada Child Benefit phase-out
+		expectedRate := 32.95
+		if result.MarginalEffectiveRate != expectedRate {
+			t.Errorf("Expected marginal effective rate %f, but got %f", expectedRate, result.MarginalEffectiveRate)
+		}
+		if result.PrimaryDriver != "federalTax" {
+			t.Errorf("Expected primary driver federalTax, but got %s", result.PrimaryDriver)
+		}
+	})
+
+	t.Run("InvalidDelta", func(t *testing.T) {
+		_, err := marginalRateService.CalculateMarginalEffectiveRate(context.Background(), taxBrackets, "2021", entity.MarginalRateInputs{
+			Salary: 60000,
+		})
+		if err == nil {
+			t.Errorf("Expected an error for a zero delta")
+		}
+	})
+}
@@ -3,9 +3,11 @@
 package tests
 
 import (
+	"context"
 	"errors"
 	"github.com/siparisa/interview-test-server/internal/entity"
-	"time"
+	"github.com/siparisa/interview-test-server/internal/service"
+	"sync"
 )
 
 // Define a mock tax service that implements the ITaxService interface.
@@ -43,7 +45,7 @@ func (m *mockTaxService) CalculateEffectiveRate(taxAmount, salary float64) (floa
 // Define a mock tax bracket service that implements the ITaxBracketService interface.
 type mockTaxBracketService struct{}
 
-func (m *mockTaxBracketService) GetTaxBracket(taxYear string, maxRetries int, retryInterval time.Duration) (*entity.TaxBrackets, error) {
+func (m *mockTaxBracketService) GetTaxBracket(ctx context.Context, taxYear string) (*entity.TaxBrackets, error) {
 	// Mock the tax brackets for the year 2019.
 	if taxYear == "2019" {
 		taxBrackets := &entity.TaxBrackets{
@@ -83,6 +85,227 @@ func (m *mockTaxBracketService) GetTaxBracket(taxYear string, maxRetries int, re
 		return taxBrackets, nil
 	}
 
+	// Mock the tax brackets for the year 2021.
+	if taxYear == "2021" {
+		taxBrackets := &entity.TaxBrackets{
+			TaxBrackets: []entity.TaxBracket{
+				{
+					Band: "band1",
+					Max:  49020,
+					Min:  0,
+					Rate: 0.15,
+				},
+				{
+					Band: "band2",
+					Max:  98040,
+					Min:  49020,
+					Rate: 0.205,
+				},
+				{
+					Band: "band3",
+					Max:  151978,
+					Min:  98040,
+					Rate: 0.26,
+				},
+				{
+					Band: "band4",
+					Max:  216511,
+					Min:  151978,
+					Rate: 0.29,
+				},
+				{
+					Band: "band5",
+					Min:  216511,
+					Rate: 0.33,
+				},
+			},
+		}
+
+		return taxBrackets, nil
+	}
+
+	// Mock an upstream that does not answer before the deadline for the year 2022.
+	if taxYear == "2022" {
+		return nil, context.DeadlineExceeded
+	}
+
 	// Return an error for other tax years.
 	return nil, errors.New("tax brackets not found for the given year")
 }
+
+func (m *mockTaxBracketService) GetJurisdictionTaxBracket(ctx context.Context, jurisdiction string, taxYear string) (*entity.TaxBrackets, error) {
+	return m.GetTaxBracket(ctx, taxYear)
+}
+
+func (m *mockTaxBracketService) GetTaxBracketHistory(jurisdiction string, taxYear string) []entity.TaxBracketVersion {
+	return nil
+}
+
+func (m *mockTaxBracketService) GetBracketImpactReports(jurisdiction string, taxYear string) []entity.BracketImpactReport {
+	return nil
+}
+
+func (m *mockTaxBracketService) CompareTaxBracketVersions(jurisdiction string, taxYear string, before int, after int, salaries []float64) (*entity.BracketImpactReport, error) {
+	return nil, service.ErrTaxBracketVersionNotFound
+}
+
+// Define a mock tax credit service that implements the ITaxCreditService interface.
+type mockTaxCreditService struct{}
+
+func (m *mockTaxCreditService) CalculateTaxCredits(taxYear string, taxBrackets *entity.TaxBrackets, salary float64, inputs entity.TaxCreditInputs) (*entity.TaxCredits, error) {
+	credits := &entity.TaxCredits{}
+	if inputs.Donations > 0 {
+		// Mock the donation credit at the lowest rate.
+		credits.Credits = append(credits.Credits, entity.TaxCredit{
+			Name:           "charitableDonations",
+			EligibleAmount: inputs.Donations,
+			Amount:         0.15 * inputs.Donations,
+		})
+		credits.TotalCreditAmount += 0.15 * inputs.Donations
+	}
+
+	return credits, nil
+}
+
+func (m *mockTaxCreditService) ApplyTaxCredits(totalTaxAmount float64, credits *entity.TaxCredits) (float64, error) {
+	return totalTaxAmount - credits.TotalCreditAmount, nil
+}
+
+// Define a mock provincial tax service that implements the IProvincialTaxService interface.
+type mockProvincialTaxService struct{}
+
+func (m *mockProvincialTaxService) GetProvincialTaxBrackets(ctx context.Context, province string, taxYear string) (*entity.TaxBrackets, error) {
+	if province != "QC" {
+		return nil, service.ErrUnsupportedProvince
+	}
+
+	return &entity.TaxBrackets{TaxBrackets: []entity.TaxBracket{{Band: "band1", Rate: 0.15}}}, nil
+}
+
+func (m *mockProvincialTaxService) CalculateProvincialTax(ctx context.Context, province string, taxYear string, salary float64) (*entity.ProvincialTaxResult, error) {
+	if province != "QC" {
+		return nil, service.ErrUnsupportedProvince
+	}
+
+	return &entity.ProvincialTaxResult{
+		Province:            province,
+		TaxAmountPerBand:    map[string]float64{"band1": 6568.5, "band2": 1245},
+		BasicPersonalCredit: 2290.35,
+		TotalTaxAmount:      5523.15,
+	}, nil
+}
+
+func (m *mockProvincialTaxService) CalculateFederalAbatement(province string, basicFederalTax float64) (float64, error) {
+	if province != "QC" {
+		return 0, service.ErrUnsupportedProvince
+	}
+
+	// Mock the 16.5% Quebec abatement of the mocked federal tax.
+	return 1259.01, nil
+}
+
+// Define a mock payroll contribution service that implements the IPayrollContributionService interface.
+type mockPayrollContributionService struct{}
+
+func (m *mockPayrollContributionService) CalculatePayrollContributions(province string, taxYear string, salary float64) (*entity.PayrollContributions, error) {
+	return &entity.PayrollContributions{
+		Contributions: []entity.PayrollContribution{
+			{Name: "QPP", Amount: 2580.75},
+			{Name: "EI", Amount: 600},
+			{Name: "QPIP", Amount: 247},
+		},
+		TotalContributionAmount: 3427.75,
+	}, nil
+}
+
+// Define a mock benefit service that implements the IBenefitService interface.
+type mockBenefitService struct{}
+
+func (m *mockBenefitService) CalculateFamilyNetIncome(salaries ...float64) (float64, error) {
+	familyNetIncome := 0.0
+	for _, salary := range salaries {
+		familyNetIncome += salary
+	}
+
+	return familyNetIncome, nil
+}
+
+func (m *mockBenefitService) EstimateBenefits(taxYear string, inputs entity.BenefitInputs) (*entity.BenefitEstimate, error) {
+	return &entity.BenefitEstimate{
+		BenefitYear:        "July 2022 - June 2023",
+		FamilyNetIncome:    inputs.FamilyNetIncome,
+		CanadaChildBenefit: 5092.79,
+		TotalBenefitAmount: 5092.79,
+	}, nil
+}
+
+func (m *mockBenefitService) GetBenefitTaxYears() []string {
+	return []string{"2019", "2020", "2021", "2022"}
+}
+
+func (m *mockBenefitService) CalculateOASRecovery(taxYear string, netIncome float64, oasIncome float64) (float64, error) {
+	return 0, nil
+}
+
+// Define a mock upstream tax bracket service that can be switched down and counts its calls.
+type mockSwitchableTaxBracketService struct {
+	mu    sync.Mutex
+	down  bool
+	calls int
+}
+
+func (m *mockSwitchableTaxBracketService) GetTaxBracket(ctx context.Context, taxYear string) (*entity.TaxBrackets, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.calls++
+	if m.down {
+		return nil, &service.BracketFetchError{TaxYear: taxYear, Attempts: 1, Reason: service.FetchFailureStatus, StatusCode: 503}
+	}
+
+	return (&mockTaxBracketService{}).GetTaxBracket(ctx, "2019")
+}
+
+func (m *mockSwitchableTaxBracketService) setDown(down bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.down = down
+}
+
+func (m *mockSwitchableTaxBracketService) callCount() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.calls
+}
+
+type mockTaxYearService struct{}
+
+func (m *mockTaxYearService) GetTaxYears() []entity.TaxYear {
+	return []entity.TaxYear{
+		{Year: "2019", Source: service.TaxBracketSourceRemote},
+		{Year: "2022", Source: service.TaxBracketSourceRemote},
+	}
+}
+
+func (m *mockTaxYearService) GetSupportedTaxYears() []string {
+	return []string{"2019", "2022"}
+}
+
+func (m *mockTaxYearService) Close() {}
+
+// stubTaxYearService supports the given tax years.
+type stubTaxYearService struct {
+	taxYears []string
+}
+
+func (s *stubTaxYearService) GetTaxYears() []entity.TaxYear {
+	return nil
+}
+
+func (s *stubTaxYearService) GetSupportedTaxYears() []string {
+	return s.taxYears
+}
+
+func (s *stubTaxYearService) Close() {}
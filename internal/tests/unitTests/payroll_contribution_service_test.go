@@ -0,0 +1,59 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/siparisa/interview-test-server/internal/service"
+)
+
+func TestCalculatePayrollContributions(t *testing.T) {
+	payrollContributionService := service.NewPayrollContributionService()
+
+	// A salary of 100000 is above the maximum insurable earnings of every contribution, so each one is at its maximum
+	for _, tc := range []struct {
+		name          string
+		province      string
+		taxYear       string
+		salary        float64
+		contributions map[string]float64
+		total         float64
+	}{
+		{name: "Ontario2019", province: "ON", taxYear: "2019", salary: 100000, contributions: map[string]float64{"CPP": 2748.9, "EI": 860.22}, total: 3609.12},
+		{name: "Ontario2020", province: "ON", taxYear: "2020", salary: 100000, contributions: map[string]float64{"CPP": 2898, "EI": 856.36}, total: 3754.36},
+		{name: "Ontario2021", province: "ON", taxYear: "2021", salary: 100000, contributions: map[string]float64{"CPP": 3166.45, "EI": 889.54}, total: 4055.99},
+		{name: "Ontario2022", province: "ON", taxYear: "2022", salary: 100000, contributions: map[string]float64{"CPP": 3499.8, "EI": 952.74}, total: 4452.54},
+		{name: "Alberta2022", province: "AB", taxYear: "2022", salary: 100000, contributions: map[string]float64{"CPP": 3499.8, "EI": 952.74}, total: 4452.54},
+		{name: "Quebec2019", province: "QC", taxYear: "2019", salary: 100000, contributions: map[string]float64{"QPP": 2991.45, "EI": 663.75, "QPIP": 402.39}, total: 4057.59},
+		{name: "Quebec2020", province: "QC", taxYear: "2020", salary: 100000, contributions: map[string]float64{"QPP": 3146.4, "EI": 650.4, "QPIP": 387.79}, total: 4184.59},
+		{name: "Quebec2021", province: "QC", taxYear: "2021", salary: 100000, contributions: map[string]float64{"QPP": 3427.9, "EI": 664.34, "QPIP": 412.49}, total: 4504.73},
+		{name: "Quebec2022", province: "QC", taxYear: "2022", salary: 100000, contributions: map[string]float64{"QPP": 3776.1, "EI": 723.6, "QPIP": 434.72}, total: 4934.42},
+		{name: "BelowMaximumEarnings", province: "QC", taxYear: "2021", salary: 40000, contributions: map[string]float64{"QPP": 2153.5, "EI": 472, "QPIP": 197.6}, total: 2823.1},
+		{name: "BelowExemption", province: "ON", taxYear: "2022", salary: 3000, contributions: map[string]float64{"CPP": 0, "EI": 47.4}, total: 47.4},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			contributions, err := payrollContributionService.CalculatePayrollContributions(tc.province, tc.taxYear, tc.salary)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			if len(contributions.Contributions) != len(tc.contributions) {
+				t.Fatalf("Expected %d contributions, but got %+v", len(tc.contributions), contributions.Contributions)
+			}
+			for _, contribution := range contributions.Contributions {
+				if expected, ok := tc.contributions[contribution.Name]; !ok || contribution.Amount != expected {
+					t.Errorf("Expected %s of %f, but got %f", contribution.Name, expected, contribution.Amount)
+				}
+			}
+			if contributions.TotalContributionAmount != tc.total {
+				t.Errorf("Expected total contribution amount %f, but got %f", tc.total, contributions.TotalContributionAmount)
+			}
+		})
+	}
+
+	t.Run("UnsupportedTaxYear", func(t *testing.T) {
+		_, err := payrollContributionService.CalculatePayrollContributions("QC", "1999", 50000)
+		if err == nil {
+			t.Errorf("Expected an error for an unsupported tax year")
+		}
+	})
+}
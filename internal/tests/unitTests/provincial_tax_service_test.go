@@ -0,0 +1,134 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"testing"
+	"time"
+
+	"github.com/siparisa/interview-test-server/internal/entity"
+	"github.com/siparisa/interview-test-server/internal/service"
+)
+
+func TestCalculateProvincialTax(t *testing.T) {
+	provincialTaxService := service.NewProvincialTaxService(service.NewTaxService(), service.NewJurisdictionTaxBracketService(&mockTaxBracketService{}, nil))
+	ctx := context.Background()
+
+	t.Run("OntarioSurtaxAndHealthPremium", func(t *testing.T) {
+		result, err := provincialTaxService.CalculateProvincialTax(ctx, "ON", "2022", 100000)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		expectedBasicTaxAmount := 6843.78
+		if result.BasicTaxAmount != expectedBasicTaxAmount {
+			t.Errorf("Expected basic tax amount %f, but got %f", expectedBasicTaxAmount, result.BasicTaxAmount)
+		}
+		expectedSurtax := 535.0
+		if result.Surtax != expectedSurtax {
+			t.Errorf("Expected surtax %f, but got %f", expectedSurtax, result.Surtax)
+		}
+		expectedHealthPremium := 750.0
+		if result.HealthPremium != expectedHealthPremium {
+			t.Errorf("Expected health premium %f, but got %f", expectedHealthPremium, result.HealthPremium)
+		}
+		expectedTotalTaxAmount := 8128.78
+		if result.TotalTaxAmount != expectedTotalTaxAmount {
+			t.Errorf("Expected total tax amount %f, but got %f", expectedTotalTaxAmount, result.TotalTaxAmount)
+		}
+	})
+
+	t.Run("QuebecScheduleAndAbatement", func(t *testing.T) {
+		result, err := provincialTaxService.CalculateProvincialTax(ctx, "QC", "2022", 80000)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		// 46295 at 15% plus 33705 at 20%, less the Quebec basic amount at 15%
+		expectedTotalTaxAmount := 11263.8
+		if result.TotalTaxAmount != expectedTotalTaxAmount {
+			t.Errorf("Expected total tax amount %f, but got %f", expectedTotalTaxAmount, result.TotalTaxAmount)
+		}
+
+		abatement, err := provincialTaxService.CalculateFederalAbatement("QC", 8000)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if abatement != 1320 {
+			t.Errorf("Expected abatement %f, but got %f", 1320.0, abatement)
+		}
+	})
+
+	t.Run("UnsupportedProvince", func(t *testing.T) {
+		_, err := provincialTaxService.CalculateProvincialTax(ctx, "XX", "2022", 80000)
+		if !errors.Is(err, service.ErrUnsupportedProvince) {
+			t.Errorf("Expected ErrUnsupportedProvince, but got %v", err)
+		}
+	})
+	t.Run("MidYearProvincialBrackets", func(t *testing.T) {
+		dir := t.TempDir()
+		modTime := time.Now().Add(-time.Hour)
+		writeBracketFile(t, dir, "on/2019.csv", csvBrackets, modTime)
+		writeBracketFile(t, dir, "on/2019@2019-07-01.csv", "min,max,rate\n0,40000,0.05\n40000,,0.09\n", modTime)
+
+		files, err := service.NewFileTaxBracketService(service.FileTaxBracketOptions{Dir: dir}, nil, log.New(io.Discard, "", 0))
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		defer files.Close()
+		datedProvincialTaxService := service.NewProvincialTaxService(service.NewTaxService(), service.NewJurisdictionTaxBracketService(&mockTaxBracketService{}, files))
+
+		for _, tc := range []struct {
+			asOf           time.Time
+			effectiveFrom  time.Time
+			basicTaxAmount float64
+		}{
+			{asOf: time.Date(2019, time.March, 1, 0, 0, 0, 0, time.UTC), effectiveFrom: time.Date(2019, time.January, 1, 0, 0, 0, 0, time.UTC), basicTaxAmount: 980.61},
+			{asOf: time.Date(2019, time.August, 1, 0, 0, 0, 0, time.UTC), effectiveFrom: time.Date(2019, time.July, 1, 0, 0, 0, 0, time.UTC), basicTaxAmount: 970.9},
+		} {
+			result, err := datedProvincialTaxService.CalculateProvincialTax(service.WithAsOf(ctx, tc.asOf), "ON", "2019", 30000)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if result.BasicTaxAmount != tc.basicTaxAmount || !result.BracketsEffectiveFrom.Equal(tc.effectiveFrom) || result.BracketSource != service.TaxBracketSourceFile {
+				t.Errorf("Expected the brackets from %s with basic tax %f on %s, but got %+v", tc.effectiveFrom, tc.basicTaxAmount, tc.asOf, result)
+			}
+		}
+
+		// Provinces without a table in the directory use the built-in brackets
+		result, err := datedProvincialTaxService.CalculateProvincialTax(ctx, "QC", "2022", 80000)
+		if err != nil || result.BracketSource != service.TaxBracketSourceEmbedded {
+			t.Errorf("Expected the built-in Quebec brackets, but got %+v, %v", result, err)
+		}
+	})
+
+	t.Run("ProvincialOverride", func(t *testing.T) {
+		overrides, err := service.NewBracketOverrideService(service.NewJurisdictionTaxBracketService(&mockTaxBracketService{}, nil), service.NewBracketImpactService(service.NewTaxService(), service.BracketImpactOptions{}), service.BracketOverrideOptions{}, log.New(io.Discard, "", 0))
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		midYear := time.Date(2019, time.July, 1, 0, 0, 0, 0, time.UTC)
+		if _, err := overrides.PublishTaxBracketOverride("ON", "2019", midYear, []entity.TaxBracket{{Min: 0, Rate: 0.05}}, "alice", ""); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if _, err := overrides.ActivateTaxBracketOverride("ON", "2019", "alice"); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		overriddenProvincialTaxService := service.NewProvincialTaxService(service.NewTaxService(), overrides)
+
+		for _, tc := range []struct {
+			asOf   time.Time
+			source string
+		}{
+			{asOf: time.Date(2019, time.March, 1, 0, 0, 0, 0, time.UTC), source: service.TaxBracketSourceEmbedded},
+			{asOf: time.Date(2019, time.August, 1, 0, 0, 0, 0, time.UTC), source: service.TaxBracketSourceOverride},
+		} {
+			result, err := overriddenProvincialTaxService.CalculateProvincialTax(service.WithAsOf(ctx, tc.asOf), "ON", "2019", 30000)
+			if err != nil || result.BracketSource != tc.source {
+				t.Errorf("Expected the %s brackets on %s, but got %+v, %v", tc.source, tc.asOf, result, err)
+			}
+		}
+	})
+}
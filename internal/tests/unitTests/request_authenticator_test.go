@@ -0,0 +1,196 @@
+package tests
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/siparisa/interview-test-server/internal/service"
+)
+
+const authTestBrackets = `{"tax_brackets":[{"min":0,"rate":0.15}]}`
+
+func TestRequestAuthenticator(t *testing.T) {
+	t.Run("APIKey", func(t *testing.T) {
+		upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("X-Provider-Key") != "secret" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			w.Write([]byte(authTestBrackets))
+		}))
+		defer upstream.Close()
+
+		taxBracketService, _ := service.NewTaxBracketService(upstream.URL+"/tax-year/", service.TaxBracketServiceOptions{
+			Authenticator: service.NewAPIKeyAuthenticator("X-Provider-Key", "secret"),
+		})
+
+		if _, err := taxBracketService.GetTaxBracket(context.Background(), "2022"); err != nil {
+			t.Errorf("Unexpected error: %v", err)
+		}
+	})
+
+	t.Run("OAuth2CachesAndRefreshesToken", func(t *testing.T) {
+		var tokenRequests, rejected int32
+		upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/token" {
+				clientID, clientSecret, _ := r.BasicAuth()
+				if r.PostFormValue("grant_type") != "client_credentials" || clientID != "calculator" || clientSecret != "secret" {
+					w.WriteHeader(http.StatusUnauthorized)
+					return
+				}
+				n := atomic.AddInt32(&tokenRequests, 1)
+				fmt.Fprintf(w, `{"access_token":"token-%d","token_type":"Bearer","expires_in":3600}`, n)
+				return
+			}
+
+			// The first token is revoked after its first use
+			if r.Header.Get("Authorization") == "Bearer token-1" && atomic.AddInt32(&rejected, 1) > 1 {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			w.Write([]byte(authTestBrackets))
+		}))
+		defer upstream.Close()
+
+		authenticator, err := service.NewOAuth2Authenticator(service.OAuth2Options{
+			TokenURL:     upstream.URL + "/token",
+			ClientID:     "calculator",
+			ClientSecret: "secret",
+		}, service.TaxBracketServiceOptions{})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		taxBracketService, _ := service.NewTaxBracketService(upstream.URL+"/tax-year/", service.TaxBracketServiceOptions{
+			Authenticator: authenticator,
+		})
+
+		if _, err := taxBracketService.GetTaxBracket(context.Background(), "2022"); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if tokenRequests != 1 {
+			t.Fatalf("Expected 1 token request, but got %d", tokenRequests)
+		}
+
+		// The revoked token is refreshed once and the request sent again
+		if _, err := taxBracketService.GetTaxBracket(context.Background(), "2022"); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if _, err := taxBracketService.GetTaxBracket(context.Background(), "2022"); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if tokenRequests != 2 {
+			t.Errorf("Expected the token to be refreshed once, but got %d token requests", tokenRequests)
+		}
+	})
+
+	t.Run("OAuth2WithoutOrShortExpiry", func(t *testing.T) {
+		for _, tokenResponse := range []string{
+			`{"access_token":"token","token_type":"Bearer"}`,
+			`{"access_token":"token","token_type":"Bearer","expires_in":20}`,
+		} {
+			var tokenRequests int32
+			upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path == "/token" {
+					atomic.AddInt32(&tokenRequests, 1)
+					w.Write([]byte(tokenResponse))
+					return
+				}
+				w.Write([]byte(authTestBrackets))
+			}))
+
+			authenticator, _ := service.NewOAuth2Authenticator(service.OAuth2Options{TokenURL: upstream.URL + "/token"}, service.TaxBracketServiceOptions{})
+			taxBracketService, _ := service.NewTaxBracketService(upstream.URL+"/tax-year/", service.TaxBracketServiceOptions{
+				Authenticator: authenticator,
+			})
+
+			for i := 0; i < 3; i++ {
+				if _, err := taxBracketService.GetTaxBracket(context.Background(), "2022"); err != nil {
+					t.Fatalf("Unexpected error: %v", err)
+				}
+			}
+			if tokenRequests != 1 {
+				t.Errorf("Expected the token of %s to be reused, but got %d token requests", tokenResponse, tokenRequests)
+			}
+			upstream.Close()
+		}
+	})
+
+	t.Run("OAuth2RejectedAfterRefresh", func(t *testing.T) {
+		var requests int32
+		upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/token" {
+				w.Write([]byte(`{"access_token":"token","expires_in":3600}`))
+				return
+			}
+			atomic.AddInt32(&requests, 1)
+			w.WriteHeader(http.StatusUnauthorized)
+		}))
+		defer upstream.Close()
+
+		authenticator, _ := service.NewOAuth2Authenticator(service.OAuth2Options{TokenURL: upstream.URL + "/token"}, service.TaxBracketServiceOptions{})
+		taxBracketService, _ := service.NewTaxBracketService(upstream.URL+"/tax-year/", service.TaxBracketServiceOptions{
+			Authenticator: authenticator,
+		})
+
+		_, err := taxBracketService.GetTaxBracket(context.Background(), "2022")
+
+		var fetchErr *service.BracketFetchError
+		if !errors.As(err, &fetchErr) || fetchErr.StatusCode != http.StatusUnauthorized {
+			t.Fatalf("Expected a 401 BracketFetchError, but got %v", err)
+		}
+		if requests != 2 {
+			t.Errorf("Expected the request to be sent again once, but got %d requests", requests)
+		}
+	})
+
+	t.Run("OAuth2TokenEndpointFailure", func(t *testing.T) {
+		upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusInternalServerError)
+		}))
+		defer upstream.Close()
+
+		authenticator, _ := service.NewOAuth2Authenticator(service.OAuth2Options{TokenURL: upstream.URL + "/token"}, service.TaxBracketServiceOptions{})
+		taxBracketService, _ := service.NewTaxBracketService(upstream.URL+"/tax-year/", service.TaxBracketServiceOptions{
+			Authenticator: authenticator,
+		})
+
+		_, err := taxBracketService.GetTaxBracket(context.Background(), "2022")
+
+		var fetchErr *service.BracketFetchError
+		if !errors.As(err, &fetchErr) || fetchErr.Reason != service.FetchFailureAuth || !errors.Is(err, service.ErrUpstreamAuthentication) {
+			t.Errorf("Expected an auth failure, but got %v", err)
+		}
+	})
+
+	t.Run("HMAC", func(t *testing.T) {
+		upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			timestamp := r.Header.Get(service.HMACTimestampHeader)
+			mac := hmac.New(sha256.New, []byte("secret"))
+			mac.Write([]byte(r.Method + "\n" + r.URL.RequestURI() + "\n" + timestamp))
+			expected := "HMAC-SHA256 KeyId=calculator, Signature=" + hex.EncodeToString(mac.Sum(nil))
+
+			if timestamp == "" || r.Header.Get("Authorization") != expected {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			w.Write([]byte(authTestBrackets))
+		}))
+		defer upstream.Close()
+
+		taxBracketService, _ := service.NewTaxBracketService(upstream.URL+"/tax-year/", service.TaxBracketServiceOptions{
+			Authenticator: service.NewHMACAuthenticator("calculator", "secret"),
+		})
+
+		if _, err := taxBracketService.GetTaxBracket(context.Background(), "2022"); err != nil {
+			t.Errorf("Unexpected error: %v", err)
+		}
+	})
+}
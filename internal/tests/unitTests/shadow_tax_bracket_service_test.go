@@ -0,0 +1,79 @@
+package tests
+
+import (
+	"context"
+	"io"
+	"log"
+	"testing"
+	"time"
+
+	"github.com/siparisa/interview-test-server/internal/entity"
+	"github.com/siparisa/interview-test-server/internal/service"
+)
+
+// waitForShadowStats polls the shadow stats until a comparison or shadow failure is recorded.
+func waitForShadowStats(t *testing.T, shadow service.IShadowTaxBracketService) entity.ShadowStats {
+	t.Helper()
+
+	for i := 0; i < 200; i++ {
+		stats := shadow.GetShadowStats()
+		if stats.Comparisons > 0 || stats.ShadowFailures > 0 {
+			return stats
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	t.Fatalf("Timed out waiting for the shadow comparison")
+	return entity.ShadowStats{}
+}
+
+func TestShadowTaxBracketService(t *testing.T) {
+	embedded, err := service.NewEmbeddedTaxBracketService()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	logger := log.New(io.Discard, "", 0)
+
+	t.Run("CountsMatch", func(t *testing.T) {
+		shadow := service.NewShadowTaxBracketService(&mockTaxBracketService{}, embedded, service.ShadowOptions{Source: "embedded"}, logger)
+
+		if _, err := shadow.GetTaxBracket(context.Background(), "2019"); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		stats := waitForShadowStats(t, shadow)
+		if stats.Matches != 1 || stats.Mismatches != 0 {
+			t.Errorf("Expected the renamed but identical bands to match, but got %+v", stats)
+		}
+	})
+
+	t.Run("CountsMismatchedBandsAndFields", func(t *testing.T) {
+		shadow := service.NewShadowTaxBracketService(&mockTaxBracketService{}, &stubTaxBracketService{rate: 0.15}, service.ShadowOptions{Source: "stub"}, logger)
+
+		taxBrackets, err := shadow.GetTaxBracket(context.Background(), "2019")
+		if err != nil || len(taxBrackets.TaxBrackets) != 5 {
+			t.Fatalf("Expected the primary brackets, but got %+v, %v", taxBrackets, err)
+		}
+
+		stats := waitForShadowStats(t, shadow)
+		if stats.Mismatches != 1 || stats.LastMismatch == nil || stats.LastMismatch.TaxYear != "2019" {
+			t.Fatalf("Expected a mismatch, but got %+v", stats)
+		}
+		if stats.MismatchedBands["2019 0-47630"] != 1 || stats.MismatchedFields["max"] != 1 || stats.MismatchedFields["removed"] != 4 {
+			t.Errorf("Expected the first band to change its max and the others to be missing, but got %+v", stats)
+		}
+	})
+
+	t.Run("DoesNotWaitForShadow", func(t *testing.T) {
+		shadow := service.NewShadowTaxBracketService(&mockTaxBracketService{}, &hangingTaxBracketService{}, service.ShadowOptions{Source: "hanging", Timeout: 20 * time.Millisecond}, logger)
+
+		start := time.Now()
+		if _, err := shadow.GetTaxBracket(context.Background(), "2019"); err != nil || time.Since(start) > 10*time.Millisecond {
+			t.Errorf("Expected the primary brackets without waiting for the shadow source, but got %v after %s", err, time.Since(start))
+		}
+
+		if stats := waitForShadowStats(t, shadow); stats.ShadowFailures != 1 {
+			t.Errorf("Expected the shadow timeout to be counted, but got %+v", stats)
+		}
+	})
+}
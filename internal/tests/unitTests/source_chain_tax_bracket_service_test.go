@@ -0,0 +1,122 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"testing"
+	"time"
+
+	"github.com/siparisa/interview-test-server/internal/entity"
+	"github.com/siparisa/interview-test-server/internal/service"
+)
+
+// hangingTaxBracketService never answers before the request is done.
+type hangingTaxBracketService struct{}
+
+func (s *hangingTaxBracketService) GetTaxBracket(ctx context.Context, taxYear string) (*entity.TaxBrackets, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+// invalidTaxBracketService answers with brackets that do not start at zero.
+type invalidTaxBracketService struct{}
+
+func (s *invalidTaxBracketService) GetTaxBracket(ctx context.Context, taxYear string) (*entity.TaxBrackets, error) {
+	return &entity.TaxBrackets{TaxBrackets: []entity.TaxBracket{{Band: "band1", Min: 100, Rate: 0.15}}}, nil
+}
+
+func TestSourceChainTaxBracketService(t *testing.T) {
+	embedded, err := service.NewEmbeddedTaxBracketService()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	upstream := &mockSwitchableTaxBracketService{}
+	chain := service.NewSourceChainTaxBracketService([]service.BracketSource{
+		{Name: service.TaxBracketSourceRemote, Service: upstream},
+		{Name: service.TaxBracketSourceEmbedded, Service: embedded},
+	}, service.SourceChainOptions{FailureThreshold: 2, RetryAfter: time.Minute}, log.New(io.Discard, "", 0))
+
+	t.Run("UsesFirstSource", func(t *testing.T) {
+		taxBrackets, err := chain.GetTaxBracket(context.Background(), "2019")
+		if err != nil || taxBrackets.Source != service.TaxBracketSourceRemote {
+			t.Errorf("Expected the remote brackets, but got %+v, %v", taxBrackets, err)
+		}
+	})
+
+	upstream.setDown(true)
+
+	t.Run("FailsOverToNextSource", func(t *testing.T) {
+		taxBrackets, err := chain.GetTaxBracket(context.Background(), "2021")
+		if err != nil || taxBrackets.Source != service.TaxBracketSourceEmbedded {
+			t.Errorf("Expected the embedded brackets, but got %+v, %v", taxBrackets, err)
+		}
+	})
+
+	t.Run("ReturnsFirstFailureForUnknownYear", func(t *testing.T) {
+		_, err := chain.GetTaxBracket(context.Background(), "1999")
+		var fetchErr *service.BracketFetchError
+		if !errors.As(err, &fetchErr) {
+			t.Errorf("Expected the remote BracketFetchError, but got %v", err)
+		}
+	})
+
+	t.Run("SkipsUnhealthySource", func(t *testing.T) {
+		health := chain.GetSourceHealth()
+		if health[0].Healthy || health[0].ConsecutiveFailures != 2 || !health[1].Healthy {
+			t.Fatalf("Expected only the remote source to be unhealthy, but got %+v", health)
+		}
+
+		calls := upstream.callCount()
+		upstream.setDown(false)
+		taxBrackets, err := chain.GetTaxBracket(context.Background(), "2021")
+		if err != nil || taxBrackets.Source != service.TaxBracketSourceEmbedded || upstream.callCount() != calls {
+			t.Errorf("Expected the unhealthy remote source to be skipped, but got %+v, %v", taxBrackets, err)
+		}
+	})
+
+	t.Run("TriesUnhealthySourceLast", func(t *testing.T) {
+		taxBrackets, err := chain.GetTaxBracket(context.Background(), "2018")
+		if err != nil || taxBrackets.Source != service.TaxBracketSourceRemote || !chain.GetSourceHealth()[0].Healthy {
+			t.Errorf("Expected the recovered remote source to serve a year the embedded tables lack, but got %+v, %v", taxBrackets, err)
+		}
+	})
+
+	t.Run("DoesNotFailOverWhenCancelled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		slowChain := service.NewSourceChainTaxBracketService([]service.BracketSource{
+			{Name: service.TaxBracketSourceRemote, Service: &hangingTaxBracketService{}},
+			{Name: service.TaxBracketSourceEmbedded, Service: embedded},
+		}, service.SourceChainOptions{}, log.New(io.Discard, "", 0))
+		if _, err := slowChain.GetTaxBracket(ctx, "2021"); err == nil {
+			t.Errorf("Expected an error for a cancelled request")
+		}
+	})
+
+	t.Run("FailsOverAfterSourceTimeout", func(t *testing.T) {
+		slowChain := service.NewSourceChainTaxBracketService([]service.BracketSource{
+			{Name: service.TaxBracketSourceRemote, Service: &hangingTaxBracketService{}, Timeout: 10 * time.Millisecond},
+			{Name: service.TaxBracketSourceEmbedded, Service: embedded},
+		}, service.SourceChainOptions{}, log.New(io.Discard, "", 0))
+
+		taxBrackets, err := slowChain.GetTaxBracket(context.Background(), "2021")
+		if err != nil || taxBrackets.Source != service.TaxBracketSourceEmbedded {
+			t.Errorf("Expected the embedded brackets after the remote timeout, but got %+v, %v", taxBrackets, err)
+		}
+	})
+
+	t.Run("FailsOverOnInvalidBrackets", func(t *testing.T) {
+		invalidChain := service.NewSourceChainTaxBracketService([]service.BracketSource{
+			{Name: service.TaxBracketSourceRemote, Service: &invalidTaxBracketService{}},
+			{Name: service.TaxBracketSourceEmbedded, Service: embedded},
+		}, service.SourceChainOptions{}, log.New(io.Discard, "", 0))
+
+		taxBrackets, err := invalidChain.GetTaxBracket(context.Background(), "2021")
+		if err != nil || taxBrackets.Source != service.TaxBracketSourceEmbedded {
+			t.Errorf("Expected the embedded brackets instead of the invalid ones, but got %+v, %v", taxBrackets, err)
+		}
+	})
+}
@@ -0,0 +1,180 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/siparisa/interview-test-server/internal/service"
+)
+
+func TestGetTaxBracket(t *testing.T) {
+	var flakyCalls, unavailableCalls, notFoundCalls int32
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/tax-year/2019":
+			w.Write([]byte(`{"tax_brackets":[{"max":47630,"min":0,"rate":0.15},{"min":47630,"rate":0.205}]}`))
+		case "/tax-year/2020":
+			// Fail twice with a Retry-After before answering
+			if atomic.AddInt32(&flakyCalls, 1) <= 2 {
+				w.Header().Set("Retry-After", "0")
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			w.Write([]byte(`{"tax_brackets":[{"min":0,"rate":0.15}]}`))
+		case "/tax-year/2021":
+			atomic.AddInt32(&unavailableCalls, 1)
+			w.WriteHeader(http.StatusServiceUnavailable)
+		case "/tax-year/2022":
+			w.Write([]byte(`not json`))
+		case "/tax-year/2018":
+			w.Write([]byte(`{"tax_brackets":[{"max":90000,"min":40000,"rate":0.2},{"max":45000,"min":0,"rate":1.5}]}`))
+		case "/tax-year/slow":
+			// Hang longer than the attempt timeout
+			select {
+			case <-r.Context().Done():
+			case <-time.After(time.Second):
+			}
+		default:
+			atomic.AddInt32(&notFoundCalls, 1)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer upstream.Close()
+
+	taxBracketService, err := service.NewTaxBracketService(upstream.URL+"/tax-year/", service.TaxBracketServiceOptions{
+		AttemptTimeout: 100 * time.Millisecond,
+		RetryPolicy: service.RetryPolicy{
+			MaxAttempts:    3,
+			InitialBackoff: time.Millisecond,
+			MaxBackoff:     5 * time.Millisecond,
+		},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	t.Run("Success", func(t *testing.T) {
+		taxBrackets, err := taxBracketService.GetTaxBracket(context.Background(), "2019")
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if len(taxBrackets.TaxBrackets) != 2 {
+			t.Fatalf("Expected 2 tax brackets, but got %d", len(taxBrackets.TaxBrackets))
+		}
+		if taxBrackets.TaxBrackets[0].Band != "0-47630" || taxBrackets.TaxBrackets[1].Band != "47630+" {
+			t.Errorf("Expected bands derived from the bounds, but got %+v", taxBrackets.TaxBrackets)
+		}
+	})
+
+	t.Run("RetriesTransientFailures", func(t *testing.T) {
+		_, err := taxBracketService.GetTaxBracket(context.Background(), "2020")
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if flakyCalls != 3 {
+			t.Errorf("Expected 3 attempts, but got %d", flakyCalls)
+		}
+	})
+
+	t.Run("ReportsFinalFailureReason", func(t *testing.T) {
+		_, err := taxBracketService.GetTaxBracket(context.Background(), "2021")
+
+		var fetchErr *service.BracketFetchError
+		if !errors.As(err, &fetchErr) {
+			t.Fatalf("Expected a BracketFetchError, but got %v", err)
+		}
+		if fetchErr.Reason != service.FetchFailureStatus || fetchErr.StatusCode != http.StatusServiceUnavailable {
+			t.Errorf("Expected a 503 status failure, but got %s %d", fetchErr.Reason, fetchErr.StatusCode)
+		}
+		if fetchErr.Attempts != 3 || unavailableCalls != 3 {
+			t.Errorf("Expected 3 attempts, but got %d", fetchErr.Attempts)
+		}
+	})
+
+	t.Run("DoesNotRetryNotFound", func(t *testing.T) {
+		_, err := taxBracketService.GetTaxBracket(context.Background(), "1999")
+
+		var fetchErr *service.BracketFetchError
+		if !errors.As(err, &fetchErr) || fetchErr.StatusCode != http.StatusNotFound {
+			t.Fatalf("Expected a 404 BracketFetchError, but got %v", err)
+		}
+		if notFoundCalls != 1 {
+			t.Errorf("Expected 1 attempt, but got %d", notFoundCalls)
+		}
+	})
+
+	t.Run("DoesNotRetryDecodeErrors", func(t *testing.T) {
+		_, err := taxBracketService.GetTaxBracket(context.Background(), "2022")
+
+		var fetchErr *service.BracketFetchError
+		if !errors.As(err, &fetchErr) || fetchErr.Reason != service.FetchFailureDecode || fetchErr.Attempts != 1 {
+			t.Fatalf("Expected a single decode failure, but got %v", err)
+		}
+	})
+
+	t.Run("RejectsInvalidBrackets", func(t *testing.T) {
+		_, err := taxBracketService.GetTaxBracket(context.Background(), "2018")
+
+		var fetchErr *service.BracketFetchError
+		if !errors.As(err, &fetchErr) || fetchErr.Reason != service.FetchFailureInvalid || fetchErr.Attempts != 1 {
+			t.Fatalf("Expected a single invalid brackets failure, but got %v", err)
+		}
+
+		var validationErr *service.BracketValidationError
+		if !errors.As(err, &validationErr) || len(validationErr.Problems) != 3 {
+			t.Errorf("Expected the rate, overlap and top bracket problems, but got %v", err)
+		}
+	})
+
+	t.Run("InvalidURL", func(t *testing.T) {
+		invalidURL, _ := service.NewTaxBracketService("http://tax calculator\x7f/tax-year/", service.TaxBracketServiceOptions{})
+		_, err := invalidURL.GetTaxBracket(context.Background(), "2019")
+
+		var fetchErr *service.BracketFetchError
+		if !errors.As(err, &fetchErr) || fetchErr.Reason != service.FetchFailureRequest || fetchErr.Attempts != 1 || fetchErr.Retryable() {
+			t.Errorf("Expected a single request failure that is not retried, but got %v", err)
+		}
+	})
+
+	t.Run("AttemptTimeout", func(t *testing.T) {
+		_, err := taxBracketService.GetTaxBracket(context.Background(), "slow")
+
+		var fetchErr *service.BracketFetchError
+		if !errors.As(err, &fetchErr) || fetchErr.Reason != service.FetchFailureNetwork {
+			t.Errorf("Expected a network failure when every attempt times out, but got %v", err)
+		}
+	})
+
+	t.Run("CancelledDuringRetryWait", func(t *testing.T) {
+		slowRetries, _ := service.NewTaxBracketService(upstream.URL+"/tax-year/", service.TaxBracketServiceOptions{
+			RetryPolicy: service.RetryPolicy{InitialBackoff: time.Second, MaxBackoff: time.Second},
+		})
+
+		ctx, cancel := context.WithCancel(context.Background())
+		time.AfterFunc(50*time.Millisecond, cancel)
+
+		start := time.Now()
+		_, err := slowRetries.GetTaxBracket(ctx, "slow")
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("Expected context.Canceled, but got %v", err)
+		}
+		if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+			t.Errorf("Expected the retries to stop on cancellation, but took %s", elapsed)
+		}
+	})
+
+	t.Run("DeadlineExceeded", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+
+		_, err := taxBracketService.GetTaxBracket(ctx, "slow")
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("Expected context.DeadlineExceeded, but got %v", err)
+		}
+	})
+}
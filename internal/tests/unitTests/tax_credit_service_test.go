@@ -0,0 +1,76 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/siparisa/interview-test-server/internal/entity"
+	"github.com/siparisa/interview-test-server/internal/service"
+)
+
+func TestCalculateTaxCredits(t *testing.T) {
+	taxCreditService := service.NewTaxCreditService()
+	taxBrackets, _ := (&mockTaxBracketService{}).GetTaxBracket(context.Background(), "2019")
+
+	t.Run("DonationsAndMedicalExpenses", func(t *testing.T) {
+		credits, err := taxCreditService.CalculateTaxCredits("2019", taxBrackets, 50000, entity.TaxCreditInputs{
+			Donations:       1000,
+			MedicalExpenses: 3000,
+		})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		// 200 at 15% plus 800 at 29%, and (3000 - 3% of 50000) at 15%
+		expectedTotalCreditAmount := 487.0
+		if credits.TotalCreditAmount != expectedTotalCreditAmount {
+			t.Errorf("Expected total credit amount %f, but got %f", expectedTotalCreditAmount, credits.TotalCreditAmount)
+		}
+		if len(credits.Credits) != 2 {
+			t.Errorf("Expected 2 itemized tax credits, but got %d", len(credits.Credits))
+		}
+	})
+
+	t.Run("DonationsFromTopBracketIncome", func(t *testing.T) {
+		credits, err := taxCreditService.CalculateTaxCredits("2019", taxBrackets, 250000, entity.TaxCreditInputs{
+			Donations: 50200,
+		})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		// 200 at 15%, 39629 of top bracket income at 33% and the remaining 10371 at 29%
+		expectedTotalCreditAmount := 16115.16
+		if credits.TotalCreditAmount != expectedTotalCreditAmount {
+			t.Errorf("Expected total credit amount %f, but got %f", expectedTotalCreditAmount, credits.TotalCreditAmount)
+		}
+	})
+
+	t.Run("CreditsDoNotMakeTaxNegative", func(t *testing.T) {
+		netTaxAmount, err := taxCreditService.ApplyTaxCredits(100, &entity.TaxCredits{TotalCreditAmount: 250})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if netTaxAmount != 0 {
+			t.Errorf("Expected net tax amount 0, but got %f", netTaxAmount)
+		}
+	})
+
+	t.Run("UnsupportedTaxYear", func(t *testing.T) {
+		_, err := taxCreditService.CalculateTaxCredits("1999", taxBrackets, 50000, entity.TaxCreditInputs{Donations: 100})
+		if !errors.Is(err, service.ErrTaxYearRulesNotFound) {
+			t.Errorf("Expected ErrTaxYearRulesNotFound for an unsupported tax year, but got %v", err)
+		}
+	})
+
+	t.Run("UnsupportedTaxYearWithoutCredits", func(t *testing.T) {
+		credits, err := taxCreditService.CalculateTaxCredits("1999", taxBrackets, 50000, entity.TaxCreditInputs{})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if len(credits.Credits) != 0 || credits.TotalCreditAmount != 0 {
+			t.Errorf("Expected no credits, but got %+v", credits)
+		}
+	})
+}
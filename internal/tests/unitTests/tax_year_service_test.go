@@ -0,0 +1,118 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/siparisa/interview-test-server/internal/service"
+)
+
+// stubTaxYearSource lists the given tax years, or fails once failing is set.
+type stubTaxYearSource struct {
+	mu       sync.Mutex
+	taxYears []string
+	failing  bool
+}
+
+func (s *stubTaxYearSource) GetTaxYears(ctx context.Context) ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.failing {
+		return nil, errors.New("source unavailable")
+	}
+	return s.taxYears, nil
+}
+
+func (s *stubTaxYearSource) set(taxYears []string, failing bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.taxYears = taxYears
+	s.failing = failing
+}
+
+func TestTaxYearService(t *testing.T) {
+	files := &stubTaxYearSource{taxYears: []string{"2023"}}
+	remote := &stubTaxYearSource{taxYears: []string{"2022", "2023"}}
+	embedded := &stubTaxYearSource{taxYears: []string{"2019", "2022"}}
+
+	taxYearService := service.NewTaxYearService([]service.TaxYearSource{
+		{Name: service.TaxBracketSourceFile, Source: files},
+		{Name: service.TaxBracketSourceRemote, Source: remote},
+		{Name: service.TaxBracketSourceEmbedded, Source: embedded},
+	}, service.TaxYearOptions{RefreshInterval: 5 * time.Millisecond}, log.New(io.Discard, "", 0))
+	defer taxYearService.Close()
+
+	t.Run("ListsYearsOfFirstSource", func(t *testing.T) {
+		taxYears := taxYearService.GetTaxYears()
+		expected := map[string]string{"2019": "embedded", "2022": "remote", "2023": "file"}
+		if len(taxYears) != len(expected) {
+			t.Fatalf("Expected %d tax years, but got %+v", len(expected), taxYears)
+		}
+		for _, taxYear := range taxYears {
+			if expected[taxYear.Year] != taxYear.Source || taxYear.RefreshedAt.IsZero() {
+				t.Errorf("Unexpected tax year %+v", taxYear)
+			}
+		}
+	})
+
+	t.Run("RefreshesNewYears", func(t *testing.T) {
+		embedded.set([]string{"2019", "2020", "2022"}, false)
+
+		for i := 0; i < 100 && len(taxYearService.GetSupportedTaxYears()) != 4; i++ {
+			time.Sleep(5 * time.Millisecond)
+		}
+		if years := taxYearService.GetSupportedTaxYears(); len(years) != 4 || years[1] != "2020" {
+			t.Errorf("Expected 2020 to be added, but got %v", years)
+		}
+	})
+
+	t.Run("KeepsYearsOfFailingSource", func(t *testing.T) {
+		remote.set(nil, true)
+		time.Sleep(20 * time.Millisecond)
+
+		for _, taxYear := range taxYearService.GetTaxYears() {
+			if taxYear.Year == "2022" && taxYear.Source != service.TaxBracketSourceRemote {
+				t.Errorf("Expected 2022 to still come from the remote source, but got %+v", taxYear)
+			}
+		}
+	})
+}
+
+func TestRemoteTaxYearSource(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/tax-years" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(`{"tax_years":["2019","2020","2021","2022","2023"]}`))
+	}))
+	defer upstream.Close()
+
+	t.Run("ListsYearIndex", func(t *testing.T) {
+		source, err := service.NewRemoteTaxYearSource(upstream.URL+"/tax-years", service.TaxBracketServiceOptions{})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		taxYears, err := source.GetTaxYears(context.Background())
+		if err != nil || len(taxYears) != 5 || taxYears[4] != "2023" {
+			t.Errorf("Expected the 5 years of the index, but got %v, %v", taxYears, err)
+		}
+	})
+
+	t.Run("FailsOnUnexpectedStatus", func(t *testing.T) {
+		source, _ := service.NewRemoteTaxYearSource(upstream.URL+"/missing", service.TaxBracketServiceOptions{})
+		if _, err := source.GetTaxYears(context.Background()); err == nil {
+			t.Errorf("Expected an error for a missing year index")
+		}
+	})
+}
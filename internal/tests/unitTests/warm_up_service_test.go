@@ -0,0 +1,100 @@
+package tests
+
+import (
+	"io"
+	"log"
+	"testing"
+	"time"
+
+	"github.com/siparisa/interview-test-server/internal/entity"
+	"github.com/siparisa/interview-test-server/internal/service"
+)
+
+// waitForWarmUp polls the warm-up status until the service is ready.
+func waitForWarmUp(t *testing.T, warmUp service.IWarmUpService) entity.WarmUpStatus {
+	t.Helper()
+
+	for i := 0; i < 200; i++ {
+		status := warmUp.GetWarmUpStatus()
+		if status.Ready {
+			return status
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	t.Fatalf("Timed out waiting for the warm-up")
+	return entity.WarmUpStatus{}
+}
+
+func TestWarmUpService(t *testing.T) {
+	logger := log.New(io.Discard, "", 0)
+
+	t.Run("FetchesSupportedTaxYears", func(t *testing.T) {
+		upstream := &mockSwitchableTaxBracketService{}
+		warmUp := service.NewWarmUpService(upstream, &mockTaxYearService{}, service.WarmUpOptions{}, logger)
+
+		status := waitForWarmUp(t, warmUp)
+		if status.State != service.WarmUpComplete || status.CompletedAt == nil {
+			t.Errorf("Expected a complete warm-up, but got %+v", status)
+		}
+		if len(status.WarmedTaxYears) != 2 || status.WarmedTaxYears[0] != "2019" || status.WarmedTaxYears[1] != "2022" {
+			t.Errorf("Expected 2019 and 2022 to be warmed, but got %v", status.WarmedTaxYears)
+		}
+		if upstream.callCount() != 2 {
+			t.Errorf("Expected 2 fetches, but got %d", upstream.callCount())
+		}
+	})
+
+	t.Run("RetriesFailedTaxYears", func(t *testing.T) {
+		upstream := &mockSwitchableTaxBracketService{down: true}
+		warmUp := service.NewWarmUpService(upstream, &mockTaxYearService{}, service.WarmUpOptions{
+			RetryInterval: 10 * time.Millisecond,
+		}, logger)
+
+		time.Sleep(20 * time.Millisecond)
+		status := warmUp.GetWarmUpStatus()
+		if status.Ready || status.State != service.WarmUpInProgress || len(status.FailedTaxYears) != 2 {
+			t.Fatalf("Expected the warm-up to be in progress with 2 failed years, but got %+v", status)
+		}
+
+		upstream.setDown(false)
+		status = waitForWarmUp(t, warmUp)
+		if status.State != service.WarmUpComplete || len(status.FailedTaxYears) != 0 || len(status.WarmedTaxYears) != 2 {
+			t.Errorf("Expected a complete warm-up after the retry, but got %+v", status)
+		}
+	})
+
+	t.Run("ReadyAfterTimeout", func(t *testing.T) {
+		upstream := &mockSwitchableTaxBracketService{down: true}
+		warmUp := service.NewWarmUpService(upstream, &mockTaxYearService{}, service.WarmUpOptions{
+			Timeout:       50 * time.Millisecond,
+			RetryInterval: 10 * time.Millisecond,
+		}, logger)
+
+		status := waitForWarmUp(t, warmUp)
+		if status.State != service.WarmUpTimedOut || len(status.WarmedTaxYears) != 0 {
+			t.Errorf("Expected the warm-up to time out, but got %+v", status)
+		}
+		if _, ok := status.FailedTaxYears["2019"]; !ok || len(status.FailedTaxYears) != 2 {
+			t.Errorf("Expected 2019 and 2022 to have failed, but got %v", status.FailedTaxYears)
+		}
+	})
+
+	t.Run("SkipsTaxYearsNoSourceHas", func(t *testing.T) {
+		embedded, err := service.NewEmbeddedTaxBracketService()
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		warmUp := service.NewWarmUpService(embedded, &stubTaxYearService{taxYears: []string{"1999", "2019"}}, service.WarmUpOptions{
+			Timeout: time.Minute,
+		}, logger)
+
+		status := waitForWarmUp(t, warmUp)
+		if status.State != service.WarmUpComplete || len(status.FailedTaxYears) != 0 {
+			t.Errorf("Expected a complete warm-up, but got %+v", status)
+		}
+		if len(status.SkippedTaxYears) != 1 || status.SkippedTaxYears[0] != "1999" || len(status.WarmedTaxYears) != 1 {
+			t.Errorf("Expected 1999 to be skipped and 2019 warmed, but got %+v", status)
+		}
+	})
+}